@@ -0,0 +1,269 @@
+package godet
+
+import (
+	"encoding/json"
+)
+
+// AXNode is a node in the accessibility tree.
+type AXNode struct {
+	NodeID        string                 `json:"nodeId"`
+	ParentID      string                 `json:"parentId,omitempty"`
+	ChildIDs      []string               `json:"childIds,omitempty"`
+	Ignored       bool                   `json:"ignored"`
+	Role          string                 `json:"role"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description,omitempty"`
+	Value         interface{}            `json:"value,omitempty"`
+	Properties    map[string]interface{} `json:"properties,omitempty"` // states and other properties (i.e. "focusable", "checked", "disabled", "level")
+	BackendNodeID int                    `json:"backendDOMNodeId,omitempty"`
+	FrameID       string                 `json:"frameId,omitempty"`
+
+	Parent   *AXNode   `json:"-"`
+	Children []*AXNode `json:"-"`
+}
+
+// State returns the value of the specified state or property (and false if the node doesn't have it).
+func (n *AXNode) State(name string) (interface{}, bool) {
+	v, ok := n.Properties[name]
+	return v, ok
+}
+
+// axValue is the wire format for AXValue
+type axValue struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+func (v *axValue) String() string {
+	if v == nil {
+		return ""
+	}
+
+	s, _ := v.Value.(string)
+	return s
+}
+
+// axNode is the wire format for AXNode
+type axNode struct {
+	NodeID     string   `json:"nodeId"`
+	Ignored    bool     `json:"ignored"`
+	Role       *axValue `json:"role"`
+	Name       *axValue `json:"name"`
+	Desc       *axValue `json:"description"`
+	Value      *axValue `json:"value"`
+	Properties []struct {
+		Name  string  `json:"name"`
+		Value axValue `json:"value"`
+	} `json:"properties"`
+	ParentID      string   `json:"parentId"`
+	ChildIDs      []string `json:"childIds"`
+	BackendNodeID int      `json:"backendDOMNodeId"`
+	FrameID       string   `json:"frameId"`
+}
+
+// decodeAXNodes decodes the list of nodes returned by the Accessibility methods and links parents and children.
+func decodeAXNodes(rawReply []byte) ([]*AXNode, error) {
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		Nodes []axNode `json:"nodes"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*AXNode, len(res.Nodes))
+	byID := map[string]*AXNode{}
+
+	for i, n := range res.Nodes {
+		node := &AXNode{
+			NodeID:        n.NodeID,
+			ParentID:      n.ParentID,
+			ChildIDs:      n.ChildIDs,
+			Ignored:       n.Ignored,
+			Role:          n.Role.String(),
+			Name:          n.Name.String(),
+			Description:   n.Desc.String(),
+			BackendNodeID: n.BackendNodeID,
+			FrameID:       n.FrameID,
+		}
+
+		if n.Value != nil {
+			node.Value = n.Value.Value
+		}
+
+		if len(n.Properties) > 0 {
+			node.Properties = map[string]interface{}{}
+
+			for _, p := range n.Properties {
+				node.Properties[p.Name] = p.Value.Value
+			}
+		}
+
+		nodes[i] = node
+		byID[node.NodeID] = node
+	}
+
+	for _, node := range nodes {
+		for _, id := range node.ChildIDs {
+			if child := byID[id]; child != nil {
+				child.Parent = node
+				node.Children = append(node.Children, child)
+			}
+		}
+	}
+
+	return nodes, nil
+}
+
+// AXTreeOption defines the functional options for GetAccessibilityTree
+type AXTreeOption func(p Params)
+
+// AXDepth sets the maximum depth of the returned tree (the default is the full tree)
+func AXDepth(depth int) AXTreeOption {
+	return func(p Params) {
+		p["depth"] = depth
+	}
+}
+
+// AXFrame returns the tree for the specified frame (the default is the main frame)
+func AXFrame(frameID string) AXTreeOption {
+	return func(p Params) {
+		p["frameId"] = frameID
+	}
+}
+
+// GetAccessibilityTree returns the accessibility tree for the page, as a list of nodes (the root node first).
+//
+// Example:
+//
+//	nodes, err := debugger.GetAccessibilityTree()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	for _, n := range nodes {
+//	    if !n.Ignored {
+//	        fmt.Println(n.Role, n.Name)
+//	    }
+//	}
+func (remote *RemoteDebugger) GetAccessibilityTree(options ...AXTreeOption) ([]*AXNode, error) {
+	params := Params{}
+	for _, o := range options {
+		o(params)
+	}
+
+	rawReply, err := remote.sendRawReplyRequest("Accessibility.getFullAXTree", params)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAXNodes(rawReply)
+}
+
+// QueryAccessibilityTree returns the (not ignored) accessibility nodes in the document with the specified role
+// and accessible name. An empty role or name matches any role or name.
+//
+// Note that the returned nodes are not linked to their parent and children.
+func (remote *RemoteDebugger) QueryAccessibilityTree(role, name string) ([]*AXNode, error) {
+	doc, err := remote.DocumentElement()
+	if err != nil {
+		return nil, err
+	}
+
+	params := Params{"backendNodeId": doc.BackendNodeID}
+
+	if role != "" {
+		params["role"] = role
+	}
+
+	if name != "" {
+		params["accessibleName"] = name
+	}
+
+	rawReply, err := remote.sendRawReplyRequest("Accessibility.queryAXTree", params)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeAXNodes(rawReply)
+}
+
+// axElements returns the elements for the accessibility nodes that are backed by DOM nodes.
+func (remote *RemoteDebugger) axElements(nodes []*AXNode, filter func(n *AXNode) bool) []*Element {
+	var elements []*Element
+
+	seen := map[int]bool{}
+
+	for _, n := range nodes {
+		if n.BackendNodeID == 0 || seen[n.BackendNodeID] || (filter != nil && !filter(n)) {
+			continue
+		}
+
+		seen[n.BackendNodeID] = true
+		elements = append(elements, remote.ElementForBackendNode(n.BackendNodeID))
+	}
+
+	return elements
+}
+
+// ByRole returns the elements with the specified ARIA role (explicit or implicit, i.e. "button", "link", "heading")
+// and accessible name. An empty name matches any name.
+//
+// Example:
+//
+//	buttons, err := debugger.ByRole("button", "Submit")
+//	if err == nil && len(buttons) > 0 {
+//	    buttons[0].Click()
+//	}
+func (remote *RemoteDebugger) ByRole(role, name string) ([]*Element, error) {
+	nodes, err := remote.QueryAccessibilityTree(role, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.axElements(nodes, nil), nil
+}
+
+// formControlRoles are the roles of the accessibility nodes for form controls (elements that can be labelled)
+var formControlRoles = map[string]bool{
+	"textbox":     true,
+	"searchbox":   true,
+	"combobox":    true,
+	"listbox":     true,
+	"checkbox":    true,
+	"radio":       true,
+	"switch":      true,
+	"slider":      true,
+	"spinbutton":  true,
+	"ColorWell":   true,
+	"Date":        true,
+	"DateTime":    true,
+	"InputTime":   true,
+	"meter":       true,
+	"progressbar": true,
+}
+
+// ByLabel returns the form controls (text fields, selects, checkboxes, radio buttons, sliders, etc.) with the specified
+// accessible name, i.e. associated with a label element or labelled by aria-label or aria-labelledby.
+// Other elements with the same name (buttons, links, headings, text) are not returned: use ByRole for those.
+//
+// Example:
+//
+//	fields, err := debugger.ByLabel("Email")
+//	if err == nil && len(fields) > 0 {
+//	    fields[0].Type("user@example.com")
+//	}
+func (remote *RemoteDebugger) ByLabel(label string) ([]*Element, error) {
+	nodes, err := remote.QueryAccessibilityTree("", label)
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.axElements(nodes, func(n *AXNode) bool {
+		return formControlRoles[n.Role]
+	}), nil
+}
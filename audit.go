@@ -0,0 +1,602 @@
+package godet
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Accessibility audit rules
+const (
+	// AuditImageAlt reports images without alternative text
+	AuditImageAlt = "image-alt"
+	// AuditControlName reports form controls (and buttons) without an accessible name
+	AuditControlName = "control-name"
+	// AuditHeadingOrder reports heading levels that increase by more than one
+	AuditHeadingOrder = "heading-order"
+	// AuditColorContrast reports text with a contrast ratio lower than WCAG AA (4.5:1, or 3:1 for large text)
+	AuditColorContrast = "color-contrast"
+)
+
+// AuditFinding is an issue found by AuditAccessibility.
+type AuditFinding struct {
+	Rule          string  `json:"rule"`
+	Message       string  `json:"message"`
+	Path          string  `json:"path"` // path of the element in the document (i.e. "html > body > div#main > img:nth-of-type(2)")
+	BackendNodeID int     `json:"backendNodeId"`
+	Role          string  `json:"role,omitempty"`
+	Name          string  `json:"name,omitempty"`
+	Contrast      float64 `json:"contrast,omitempty"` // contrast ratio, for AuditColorContrast
+}
+
+// AuditReport is the result of AuditAccessibility.
+type AuditReport struct {
+	URL      string         `json:"url"`
+	Title    string         `json:"title"`
+	Time     time.Time      `json:"time"`
+	Findings []AuditFinding `json:"findings"`
+}
+
+// AuditOption defines the functional options for AuditAccessibility
+type AuditOption func(a *auditor)
+
+// AuditRules sets the rules to check (the default is all rules)
+func AuditRules(rules ...string) AuditOption {
+	return func(a *auditor) {
+		a.rules = map[string]bool{}
+		for _, r := range rules {
+			a.rules[r] = true
+		}
+	}
+}
+
+// controlRoles are the roles of the elements that require an accessible name
+var controlRoles = map[string]bool{
+	"button":     true,
+	"checkbox":   true,
+	"combobox":   true,
+	"listbox":    true,
+	"radio":      true,
+	"searchbox":  true,
+	"slider":     true,
+	"spinbutton": true,
+	"switch":     true,
+	"textbox":    true,
+}
+
+// auditNode is a DOM node, as returned by GetDocument
+type auditNode struct {
+	nodeID    int
+	backendID int
+	nodeType  int
+	name      string
+	attrs     map[string]string
+	parent    *auditNode
+	segment   string // path segment
+}
+
+type auditor struct {
+	remote *RemoteDebugger
+	rules  map[string]bool
+	nodes  map[int]*auditNode // by backend node id
+	order  []*auditNode       // in document order
+	styles map[int]map[string]string
+	report *AuditReport
+}
+
+// AuditAccessibility checks the rendered page for common accessibility issues: images without alternative text,
+// form controls without accessible names, heading order gaps and low color contrast.
+// Note that this enables DOM and CSS events.
+//
+// Example:
+//
+//	report, err := debugger.AuditAccessibility()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	f, _ := os.Create("a11y.html")
+//	report.WriteHTML(f)
+//	f.Close()
+func (remote *RemoteDebugger) AuditAccessibility(options ...AuditOption) (*AuditReport, error) {
+	a := &auditor{
+		remote: remote,
+		rules:  map[string]bool{AuditImageAlt: true, AuditControlName: true, AuditHeadingOrder: true, AuditColorContrast: true},
+		nodes:  map[int]*auditNode{},
+		styles: map[int]map[string]string{},
+		report: &AuditReport{Time: time.Now(), Findings: []AuditFinding{}},
+	}
+
+	for _, o := range options {
+		o(a)
+	}
+
+	url, _ := remote.Evaluate("document.URL")
+	title, _ := remote.Evaluate("document.title")
+	a.report.URL, _ = url.(string)
+	a.report.Title, _ = title.(string)
+
+	if err := remote.DOMEvents(true); err != nil {
+		return nil, err
+	}
+
+	doc, err := remote.GetDocument(Depth(-1), Pierce(true))
+	if err != nil {
+		return nil, err
+	}
+
+	if root, ok := doc["root"].(map[string]interface{}); ok {
+		a.addNode(root, nil)
+	}
+
+	tree, err := remote.GetAccessibilityTree()
+	if err != nil {
+		return nil, err
+	}
+
+	if a.rules[AuditImageAlt] {
+		a.checkImages(tree)
+	}
+
+	if a.rules[AuditControlName] {
+		a.checkControls(tree)
+	}
+
+	if a.rules[AuditHeadingOrder] && len(tree) > 0 {
+		a.checkHeadings(tree[0])
+	}
+
+	if a.rules[AuditColorContrast] {
+		if err := remote.CSSEvents(true); err != nil {
+			return nil, err
+		}
+
+		if err := a.checkContrast(tree); err != nil {
+			return nil, err
+		}
+	}
+
+	return a.report, nil
+}
+
+// addNode indexes the node (as returned by GetDocument) and its descendants.
+func (a *auditor) addNode(node map[string]interface{}, parent *auditNode) *auditNode {
+	p := Params(node)
+
+	n := &auditNode{
+		nodeID:    p.Int("nodeId"),
+		backendID: p.Int("backendNodeId"),
+		nodeType:  p.Int("nodeType"),
+		name:      strings.ToLower(p.String("localName")),
+		parent:    parent,
+	}
+
+	if attrs, _ := node["attributes"].([]interface{}); len(attrs) > 0 {
+		n.attrs = map[string]string{}
+
+		for i := 0; i+1 < len(attrs); i += 2 {
+			name, _ := attrs[i].(string)
+			value, _ := attrs[i+1].(string)
+			n.attrs[name] = value
+		}
+	}
+
+	switch n.nodeType {
+	case 1: // ELEMENT_NODE
+		n.segment = n.name
+		if id := n.attrs["id"]; id != "" {
+			n.segment += "#" + id
+		}
+	case 9: // DOCUMENT_NODE
+		if parent != nil {
+			n.segment = "#document"
+		}
+	case 11: // DOCUMENT_FRAGMENT_NODE
+		n.segment = "#shadow-root"
+	}
+
+	a.nodes[n.backendID] = n
+	a.order = append(a.order, n)
+
+	for _, key := range []string{"shadowRoots", "children"} {
+		list, _ := node[key].([]interface{})
+
+		var children []*auditNode
+		for _, c := range list {
+			if child, ok := c.(map[string]interface{}); ok {
+				children = append(children, a.addNode(child, n))
+			}
+		}
+
+		// disambiguate elements without id with the same tag, by position among all the siblings with the same tag
+		count := map[string]int{}
+		for _, c := range children {
+			if c.nodeType == 1 {
+				count[c.name]++
+			}
+		}
+
+		index := map[string]int{}
+		for _, c := range children {
+			if c.nodeType != 1 {
+				continue
+			}
+
+			index[c.name]++
+
+			if c.attrs["id"] == "" && count[c.name] > 1 {
+				c.segment += fmt.Sprintf(":nth-of-type(%d)", index[c.name])
+			}
+		}
+	}
+
+	if doc, ok := node["contentDocument"].(map[string]interface{}); ok {
+		a.addNode(doc, n)
+	}
+
+	return n
+}
+
+// path returns the path of the node in the document.
+func (a *auditor) path(backendNodeID int) string {
+	var segments []string
+
+	for n := a.nodes[backendNodeID]; n != nil; n = n.parent {
+		if n.segment != "" {
+			segments = append([]string{n.segment}, segments...)
+		}
+	}
+
+	return strings.Join(segments, " > ")
+}
+
+func (a *auditor) add(rule string, n *AXNode, backendNodeID int, message string) *AuditFinding {
+	f := AuditFinding{
+		Rule:          rule,
+		Message:       message,
+		Path:          a.path(backendNodeID),
+		BackendNodeID: backendNodeID,
+	}
+
+	if n != nil {
+		f.Role, f.Name = n.Role, n.Name
+	}
+
+	a.report.Findings = append(a.report.Findings, f)
+	return &a.report.Findings[len(a.report.Findings)-1]
+}
+
+func (a *auditor) checkImages(tree []*AXNode) {
+	reported := map[int]bool{}
+
+	for _, n := range tree {
+		if !n.Ignored && (n.Role == "image" || n.Role == "img") && strings.TrimSpace(n.Name) == "" {
+			a.add(AuditImageAlt, n, n.BackendNodeID, "image without alternative text")
+			reported[n.BackendNodeID] = true
+		}
+	}
+
+	// images without alt attribute that are not exposed as images (an empty alt marks decorative images)
+	for _, dn := range a.order {
+		if dn.nodeType != 1 || dn.name != "img" || reported[dn.backendID] {
+			continue
+		}
+
+		if _, ok := dn.attrs["alt"]; ok {
+			continue
+		}
+
+		if dn.attrs["aria-label"] != "" || dn.attrs["aria-labelledby"] != "" || dn.attrs["title"] != "" {
+			continue
+		}
+
+		if role := dn.attrs["role"]; role == "presentation" || role == "none" {
+			continue
+		}
+
+		a.add(AuditImageAlt, nil, dn.backendID, "img element without alt attribute")
+	}
+}
+
+func (a *auditor) checkControls(tree []*AXNode) {
+	for _, n := range tree {
+		if !n.Ignored && controlRoles[n.Role] && strings.TrimSpace(n.Name) == "" {
+			a.add(AuditControlName, n, n.BackendNodeID, fmt.Sprintf("%v without accessible name", n.Role))
+		}
+	}
+}
+
+func (a *auditor) checkHeadings(root *AXNode) {
+	prev := 0
+
+	var walk func(n *AXNode)
+	walk = func(n *AXNode) {
+		if !n.Ignored && n.Role == "heading" {
+			if v, ok := n.State("level"); ok {
+				level := int(toFloat(v))
+
+				if prev > 0 && level > prev+1 {
+					a.add(AuditHeadingOrder, n, n.BackendNodeID,
+						fmt.Sprintf("heading level %d follows heading level %d", level, prev))
+				}
+
+				prev = level
+			}
+		}
+
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+
+	walk(root)
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+
+	return 0
+}
+
+// computedStyle returns the computed style for the node (cached).
+func (a *auditor) computedStyle(n *auditNode) (map[string]string, error) {
+	if style, ok := a.styles[n.nodeID]; ok {
+		return style, nil
+	}
+
+	res, err := a.remote.GetComputedStyleForNode(n.nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	style := map[string]string{}
+
+	list, _ := res["computedStyle"].([]interface{})
+	for _, p := range list {
+		prop := Params(p.(map[string]interface{}))
+		style[prop.String("name")] = prop.String("value")
+	}
+
+	a.styles[n.nodeID] = style
+	return style, nil
+}
+
+func (a *auditor) checkContrast(tree []*AXNode) error {
+	checked := map[*auditNode]bool{}
+
+	for _, n := range tree {
+		if n.Ignored || n.Role != "StaticText" || strings.TrimSpace(n.Name) == "" {
+			continue
+		}
+
+		// the text node parent element
+		tn := a.nodes[n.BackendNodeID]
+		if tn == nil || tn.parent == nil || tn.parent.nodeType != 1 || checked[tn.parent] {
+			continue
+		}
+
+		el := tn.parent
+		checked[el] = true
+
+		style, err := a.computedStyle(el)
+		if err != nil {
+			return err
+		}
+
+		fg, ok := parseColor(style["color"])
+		if !ok {
+			continue
+		}
+
+		bg, ok, err := a.background(el)
+		if err != nil {
+			return err
+		}
+
+		if !ok { // background image, or unknown color
+			continue
+		}
+
+		if opacity, err := strconv.ParseFloat(style["opacity"], 64); err == nil {
+			fg.a *= opacity
+		}
+
+		ratio := contrastRatio(fg.over(bg), bg)
+
+		required := 4.5
+		if largeText(style) {
+			required = 3
+		}
+
+		if ratio < required {
+			f := a.add(AuditColorContrast, nil, el.backendID,
+				fmt.Sprintf("contrast ratio %.2f:1 is lower than %.1f:1 (text %q)", ratio, required, limitText(n.Name, 40)))
+			f.Contrast = math.Round(ratio*100) / 100
+		}
+	}
+
+	return nil
+}
+
+func limitText(s string, l int) string {
+	if r := []rune(s); len(r) > l {
+		return string(r[:l]) + "..."
+	}
+
+	return s
+}
+
+// background returns the effective background color of the element, blending the ancestors backgrounds
+// (the page background is assumed to be white). It returns false if the background includes an image.
+func (a *auditor) background(el *auditNode) (rgba, bool, error) {
+	var layers []rgba
+
+	for n := el; n != nil; n = n.parent {
+		if n.nodeType != 1 {
+			if n.nodeType == 9 { // stop at the document
+				break
+			}
+
+			continue
+		}
+
+		style, err := a.computedStyle(n)
+		if err != nil {
+			return rgba{}, false, err
+		}
+
+		if img := style["background-image"]; img != "" && img != "none" {
+			return rgba{}, false, nil
+		}
+
+		bg, ok := parseColor(style["background-color"])
+		if !ok {
+			return rgba{}, false, nil
+		}
+
+		if bg.a > 0 {
+			layers = append(layers, bg)
+		}
+
+		if bg.a >= 1 {
+			break
+		}
+	}
+
+	color := rgba{255, 255, 255, 1}
+	for i := len(layers) - 1; i >= 0; i-- {
+		color = layers[i].over(color)
+	}
+
+	return color, true, nil
+}
+
+func largeText(style map[string]string) bool {
+	size, _ := strconv.ParseFloat(strings.TrimSuffix(style["font-size"], "px"), 64)
+
+	weight, err := strconv.Atoi(style["font-weight"])
+	if err != nil && style["font-weight"] == "bold" {
+		weight = 700
+	}
+
+	// 18pt, or 14pt bold
+	return size >= 24 || (size >= 18.66 && weight >= 700)
+}
+
+type rgba struct {
+	r, g, b, a float64
+}
+
+var reColor = regexp.MustCompile(`^rgba?\(\s*([\d.]+)[\s,]+([\d.]+)[\s,]+([\d.]+)(?:[\s,/]+([\d.]+)(%?))?\s*\)$`)
+
+// parseColor parses a computed color (rgb or rgba).
+func parseColor(s string) (c rgba, ok bool) {
+	if s == "transparent" {
+		return rgba{}, true
+	}
+
+	m := reColor.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return c, false
+	}
+
+	c.r, _ = strconv.ParseFloat(m[1], 64)
+	c.g, _ = strconv.ParseFloat(m[2], 64)
+	c.b, _ = strconv.ParseFloat(m[3], 64)
+	c.a = 1
+
+	if m[4] != "" {
+		c.a, _ = strconv.ParseFloat(m[4], 64)
+		if m[5] == "%" {
+			c.a /= 100
+		}
+	}
+
+	return c, true
+}
+
+// over blends the color over an opaque background.
+func (c rgba) over(bg rgba) rgba {
+	return rgba{
+		r: c.r*c.a + bg.r*(1-c.a),
+		g: c.g*c.a + bg.g*(1-c.a),
+		b: c.b*c.a + bg.b*(1-c.a),
+		a: 1,
+	}
+}
+
+// luminance returns the WCAG relative luminance of the color.
+func (c rgba) luminance() float64 {
+	channel := func(v float64) float64 {
+		v /= 255
+		if v <= 0.03928 {
+			return v / 12.92
+		}
+
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+
+	return 0.2126*channel(c.r) + 0.7152*channel(c.g) + 0.0722*channel(c.b)
+}
+
+// contrastRatio returns the WCAG contrast ratio between two opaque colors.
+func contrastRatio(c1, c2 rgba) float64 {
+	l1, l2 := c1.luminance(), c2.luminance()
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// WriteJSON writes the report as JSON.
+func (r *AuditReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Accessibility report - {{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #eee; }
+code { font-size: 90%; }
+</style>
+</head>
+<body>
+<h1>Accessibility report</h1>
+<p><a href="{{.URL}}">{{.URL}}</a> - {{.Time.Format "2006-01-02 15:04:05"}} - {{len .Findings}} findings</p>
+{{if .Findings}}
+<table>
+<tr><th>Rule</th><th>Message</th><th>Element</th><th>Role</th><th>Name</th></tr>
+{{range .Findings}}<tr><td>{{.Rule}}</td><td>{{.Message}}</td><td><code>{{.Path}}</code></td><td>{{.Role}}</td><td>{{.Name}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>No issues found.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as an HTML page.
+func (r *AuditReport) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
@@ -0,0 +1,206 @@
+package godet
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Point is a point in CSS pixels.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Quad is a quadrilateral, as 4 points (x1, y1, x2, y2, x3, y3, x4, y4) in clockwise order starting from the top left.
+// Quads are not necessarily rectangles (i.e. for transformed elements).
+type Quad [8]float64
+
+// Points returns the quad vertices.
+func (q Quad) Points() [4]Point {
+	return [4]Point{{q[0], q[1]}, {q[2], q[3]}, {q[4], q[5]}, {q[6], q[7]}}
+}
+
+// Center returns the center of the quad (the average of the vertices).
+func (q Quad) Center() Point {
+	return Point{X: (q[0] + q[2] + q[4] + q[6]) / 4, Y: (q[1] + q[3] + q[5] + q[7]) / 4}
+}
+
+// Bounds returns the bounding rectangle of the quad.
+func (q Quad) Bounds() Rect {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, p := range q.Points() {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+
+	return Rect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// Area returns the area of the quad.
+func (q Quad) Area() float64 {
+	area := 0.0
+
+	p := q.Points()
+	for i := range p {
+		j := (i + 1) % len(p)
+		area += p[i].X*p[j].Y - p[j].X*p[i].Y
+	}
+
+	return math.Abs(area) / 2
+}
+
+// Center returns the center of the rectangle.
+func (r Rect) Center() Point {
+	return Point{X: r.X + r.Width/2, Y: r.Y + r.Height/2}
+}
+
+// Empty returns true if the rectangle has no area.
+func (r Rect) Empty() bool {
+	return r.Width <= 0 || r.Height <= 0
+}
+
+// Contains returns true if the point is inside the rectangle.
+func (r Rect) Contains(p Point) bool {
+	return p.X >= r.X && p.X < r.X+r.Width && p.Y >= r.Y && p.Y < r.Y+r.Height
+}
+
+// Intersect returns the intersection of the two rectangles (an empty rectangle if they don't intersect).
+func (r Rect) Intersect(o Rect) Rect {
+	x1, y1 := math.Max(r.X, o.X), math.Max(r.Y, o.Y)
+	x2, y2 := math.Min(r.X+r.Width, o.X+o.Width), math.Min(r.Y+r.Height, o.Y+o.Height)
+
+	if x2 <= x1 || y2 <= y1 {
+		return Rect{}
+	}
+
+	return Rect{X: x1, Y: y1, Width: x2 - x1, Height: y2 - y1}
+}
+
+// ShapeOutside describes the CSS shape-outside of an element.
+type ShapeOutside struct {
+	Bounds      Quad          `json:"bounds"`      // Shape bounds
+	Shape       []interface{} `json:"shape"`       // Shape coordinate details
+	MarginShape []interface{} `json:"marginShape"` // Margin shape bounds
+}
+
+// BoxModel contains the element boxes (see GetBoxModel). The coordinates are relative to the main frame viewport.
+type BoxModel struct {
+	Content      Quad          `json:"content"` // Content box
+	Padding      Quad          `json:"padding"` // Padding box
+	Border       Quad          `json:"border"`  // Border box
+	Margin       Quad          `json:"margin"`  // Margin box
+	Width        int           `json:"width"`   // Node width
+	Height       int           `json:"height"`  // Node height
+	ShapeOutside *ShapeOutside `json:"shapeOutside,omitempty"`
+}
+
+// Center returns the center of the content box.
+func (b *BoxModel) Center() Point {
+	return b.Content.Center()
+}
+
+// Bounds returns the bounding rectangle of the border box.
+func (b *BoxModel) Bounds() Rect {
+	return b.Border.Bounds()
+}
+
+// decodeBoxModel decodes the reply of DOM.getBoxModel
+func decodeBoxModel(rawReply []byte) (*BoxModel, error) {
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		Model *BoxModel `json:"model"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	if res.Model == nil {
+		return nil, ErrorNoResponse
+	}
+
+	return res.Model, nil
+}
+
+// decodeQuads decodes the reply of DOM.getContentQuads
+func decodeQuads(rawReply []byte) ([]Quad, error) {
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		Quads []Quad `json:"quads"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Quads, nil
+}
+
+// GetContentQuads returns the quads describing a DOM node identified by nodeId.
+// Inline elements split across lines have multiple quads, one for each line box.
+func (remote *RemoteDebugger) GetContentQuads(nodeID int) ([]Quad, error) {
+	rawReply, err := remote.sendRawReplyRequest("DOM.getContentQuads", Params{
+		"nodeId": nodeID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeQuads(rawReply)
+}
+
+// GetViewport returns the visible area of the main frame (the layout viewport, without scrollbars),
+// in the same coordinates as the box model.
+func (remote *RemoteDebugger) GetViewport() (Rect, error) {
+	rawReply, err := remote.sendRawReplyRequest("Page.getLayoutMetrics", nil)
+	if err != nil {
+		return Rect{}, err
+	}
+
+	if rawReply == nil {
+		return Rect{}, ErrorNoResponse
+	}
+
+	var metrics struct {
+		Viewport struct {
+			ClientWidth  float64 `json:"clientWidth"`
+			ClientHeight float64 `json:"clientHeight"`
+		} `json:"cssLayoutViewport"`
+	}
+
+	if err := json.Unmarshal(rawReply, &metrics); err != nil {
+		return Rect{}, err
+	}
+
+	return Rect{Width: metrics.Viewport.ClientWidth, Height: metrics.Viewport.ClientHeight}, nil
+}
+
+// clickablePoint returns the center of the largest visible part of the quads (quads smaller than 1 pixel are ignored).
+func clickablePoint(quads []Quad, viewport Rect) (Point, bool) {
+	var best Rect
+
+	for _, q := range quads {
+		if q.Area() < 1 {
+			continue
+		}
+
+		visible := q.Bounds().Intersect(viewport)
+		if !visible.Empty() && visible.Width*visible.Height > best.Width*best.Height {
+			best = visible
+		}
+	}
+
+	if best.Empty() {
+		return Point{}, false
+	}
+
+	return best.Center(), true
+}
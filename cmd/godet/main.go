@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -122,7 +123,7 @@ func main() {
 	fetch := flag.Bool("fetch", false, "enable processing of requestPaused events (in the Fetch domain)")
 	allEvents := flag.Bool("all-events", false, "enable all events")
 	logev := flag.Bool("log", false, "show log/console messages")
-	query := flag.String("query", "", "query against current document")
+	query := flag.String("query", "", "query against current document (CSS selector, xpath=expression or text=text)")
 	eval := flag.String("eval", "", "evaluate expression")
 	screenshot := flag.Bool("screenshot", false, "take a screenshot")
 	pdf := flag.Bool("pdf", false, "save current page as PDF")
@@ -134,7 +135,8 @@ func main() {
 	wait := flag.Bool("wait", false, "wait for more events")
 	box := flag.Bool("box", false, "get box model for document")
 	styles := flag.Bool("styles", false, "get computed style for document")
-	pause := flag.Duration("pause", 5*time.Second, "wait this amount of time before proceeding")
+	a11y := flag.String("a11y", "", "write an accessibility audit report for the current page (.json or .html file, - for stdout)")
+	pause := flag.Duration("pause", 5*time.Second, "wait up to this amount of time for the network to be idle before proceeding")
 	close := flag.Bool("close", false, "gracefully close browser")
 	getCookies := flag.Bool("cookies", false, "get cookies for current page")
 	getAllCookies := flag.Bool("all-cookies", false, "get all cookies for current page")
@@ -183,8 +185,6 @@ func main() {
 	done := make(chan bool)
 	shouldWait := *wait
 
-	var pwait chan bool
-
 	v, err := remote.Version()
 	if err != nil {
 		log.Fatal("cannot get version: ", err)
@@ -252,10 +252,6 @@ func main() {
 		done <- true
 	})
 
-	remote.CallbackEvent("Emulation.virtualTimeBudgetExpired", func(params godet.Params) {
-		pwait <- true
-	})
-
 	if *requests {
 		remote.CallbackEvent("Network.requestWillBeSent", func(params godet.Params) {
 			log.Println("requestWillBeSent",
@@ -418,7 +414,6 @@ func main() {
 	}
 
 	if *control != "" {
-		remote.SetControlNavigations(true)
 		navigationResponse := godet.NavigationProceed
 
 		switch *control {
@@ -430,10 +425,9 @@ func main() {
 			navigationResponse = godet.NavigationCancelAndIgnore
 		}
 
-		remote.CallbackEvent("Page.navigationRequested", func(params godet.Params) {
-			log.Println("navigation requested for", params.String("url"), navigationResponse)
-
-			remote.ProcessNavigation(params.Int("navigationId"), navigationResponse)
+		remote.SetNavigationPolicy(func(req godet.NavigationRequest) godet.NavigationResponse {
+			log.Println("navigation requested for", req.URL, navigationResponse)
+			return navigationResponse
 		})
 	}
 
@@ -469,11 +463,17 @@ func main() {
 		})
 	}
 
+	var tracker *godet.NetworkIdleTracker
+
 	if *pause > 0 && shouldWait {
-		pwait = make(chan bool)
+		tracker, err = remote.NewNetworkIdleTracker(godet.IgnoreResourceTypes(
+			godet.ResourceTypeEventSource,
+			godet.ResourceTypeWebSocket))
+		if err != nil {
+			log.Fatal("cannot track network requests: ", err)
+		}
 
-		remote.SetVirtualTimePolicy(godet.VirtualTimePolicyPauseIfNetworkFetchesPending,
-			int(*pause/time.Millisecond))
+		defer tracker.Close()
 	}
 
 	if len(site) > 0 {
@@ -483,9 +483,14 @@ func main() {
 		}
 	}
 
-	if pwait != nil {
+	if tracker != nil {
 		fmt.Println("Pause", *pause)
-		<-pwait
+
+		ctx, cancel := context.WithTimeout(context.Background(), *pause)
+		if err := tracker.WaitIdle(ctx, 0, 500*time.Millisecond); err != nil {
+			log.Println("network not idle:", err)
+		}
+		cancel()
 	}
 
 	if *query != "" {
@@ -561,12 +566,12 @@ func main() {
 
 		id = int(res["nodeId"].(float64))
 
-		res, err = remote.GetBoxModel(id)
+		model, err := remote.GetBoxModel(id)
 		if err != nil {
 			log.Fatal("error in getBoxModel: ", err)
 		}
 
-		pretty.PrettyPrint(res)
+		pretty.PrettyPrint(model)
 		shouldWait = false
 	}
 
@@ -589,6 +594,36 @@ func main() {
 		shouldWait = false
 	}
 
+	if *a11y != "" {
+		report, err := remote.AuditAccessibility()
+		if err != nil {
+			log.Fatal("error in auditAccessibility: ", err)
+		}
+
+		if *a11y == "-" {
+			err = report.WriteJSON(os.Stdout)
+		} else {
+			f, ferr := os.Create(*a11y)
+			if ferr != nil {
+				log.Fatal("cannot create report: ", ferr)
+			}
+
+			if strings.HasSuffix(strings.ToLower(*a11y), ".html") {
+				err = report.WriteHTML(f)
+			} else {
+				err = report.WriteJSON(f)
+			}
+
+			f.Close()
+		}
+
+		if err != nil {
+			log.Fatal("error writing report: ", err)
+		}
+
+		shouldWait = false
+	}
+
 	if *screenshot {
 		id := documentNode(remote, *verbose)
 
@@ -599,19 +634,11 @@ func main() {
 
 		id = int(res["nodeId"].(float64))
 
-		res, err = remote.GetBoxModel(id)
+		model, err := remote.GetBoxModel(id)
 		if err != nil {
-			log.Fatal("error in getBoxModel: ", err)
-		}
-
-		if res == nil {
-			log.Println("BoxModel not available")
+			log.Println("BoxModel not available:", err)
 		} else {
-			res = res["model"].(map[string]interface{})
-			width := int(res["width"].(float64))
-			height := int(res["height"].(float64))
-
-			err = remote.SetVisibleSize(width, height)
+			err = remote.SetVisibleSize(model.Width, model.Height)
 			if err != nil {
 				log.Fatal("error in setVisibleSize: ", err)
 			}
@@ -105,6 +105,53 @@ func documentNode(remote *godet.RemoteDebugger, verbose bool) int {
 	return doc.GetPath("root", "nodeId").MustInt(-1)
 }
 
+// queryElements returns the nodes matching the selector, traversing open and closed shadow roots.
+func queryElements(remote *godet.RemoteDebugger, selector string, all bool) any {
+	var elements []*godet.Element
+
+	if all {
+		list, err := remote.QueryElements(selector, godet.PierceClosedShadow())
+		if err != nil {
+			return err
+		}
+
+		elements = list
+	} else {
+		elem, err := remote.QueryElement(selector, godet.PierceClosedShadow())
+		if err != nil {
+			return err
+		}
+
+		if elem == nil {
+			return nil
+		}
+
+		elements = append(elements, elem)
+	}
+
+	res := map[string]any{}
+
+	for i, elem := range elements {
+		id, err := elem.NodeID()
+		if err != nil {
+			return err
+		}
+
+		node, err := remote.ResolveNode(id)
+		if err != nil {
+			return err
+		}
+
+		if all {
+			res[fmt.Sprintf("%d", i)] = node
+		} else {
+			res[fmt.Sprintf("%d", id)] = node
+		}
+	}
+
+	return res
+}
+
 func chromeApp() (chromeapp string) {
 	switch runtime.GOOS {
 	case "darwin":
@@ -169,7 +216,7 @@ func main() {
 		responses := flag.Bool("responses", false, "show response notifications")
 		allEvents := flag.Bool("all-events", false, "enable all events")
 		logev := flag.Bool("log", false, "show log/console messages")
-		query := flag.String("query", "", "query against current document")
+		query := flag.String("query", "", "query against current document (CSS selector, xpath=expression or text=text)")
 		eval := flag.String("eval", "", "evaluate expression")
 		screenshot := flag.Bool("screenshot", false, "take a screenshot")
 		pdf := flag.Bool("pdf", false, "save current page as PDF")
@@ -361,12 +408,25 @@ func main() {
 
 	commander.Add(cmd.Command{
 		"query",
-		`query [-all] selector`,
+		`query [-all] [-pierce] selector (CSS selector, xpath=expression or text=text, -pierce to search in shadow roots)`,
 		func(line string) (stop bool) {
-			all := false
-			if strings.HasPrefix(line, "-all ") {
-				line = strings.TrimPrefix(line, "-all ")
-				all = true
+			all, pierce := false, false
+
+			for {
+				if strings.HasPrefix(line, "-all ") {
+					line = strings.TrimPrefix(line, "-all ")
+					all = true
+				} else if strings.HasPrefix(line, "-pierce ") {
+					line = strings.TrimPrefix(line, "-pierce ")
+					pierce = true
+				} else {
+					break
+				}
+			}
+
+			if pierce {
+				setResult(queryElements(remote, line, all))
+				return
 			}
 
 			id := documentNode(remote, *verbose)
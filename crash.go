@@ -0,0 +1,299 @@
+package godet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CrashReason describes why the current tab was considered crashed
+type CrashReason string
+
+const (
+	// CrashReasonCrashed is reported when the renderer process crashes (Inspector.targetCrashed or Target.targetCrashed)
+	CrashReasonCrashed = CrashReason("crashed")
+	// CrashReasonDetached is reported when the debugging session is unexpectedly detached from the target (Inspector.detached),
+	// i.e. not because the tab was closed
+	CrashReasonDetached = CrashReason("detached")
+	// CrashReasonHung is reported when the renderer doesn't reply to the liveness probe in time
+	CrashReasonHung = CrashReason("hung")
+)
+
+// RecoveryMode defines what EnableCrashDetection should do after a crash
+type RecoveryMode int
+
+const (
+	// NoRecovery only reports the crash
+	NoRecovery RecoveryMode = iota
+	// RecoverReload reloads the crashed page (a new tab is created if the target was detached or hung)
+	RecoverReload
+	// RecoverNewTab closes the crashed tab and opens the same URL in a new tab
+	RecoverNewTab
+)
+
+// CrashEvent contains information about a crash of the current tab
+type CrashEvent struct {
+	TargetID  string      // the crashed target (tab) id
+	Reason    CrashReason // crashed, detached or hung
+	Detail    string      // detach reason or termination status, if available
+	ErrorCode int         // termination error code, if available
+}
+
+// Params returns the crash information as event parameters.
+func (ev CrashEvent) Params() Params {
+	return Params{
+		"targetId":  ev.TargetID,
+		"reason":    string(ev.Reason),
+		"detail":    ev.Detail,
+		"errorCode": float64(ev.ErrorCode),
+	}
+}
+
+// CrashOption defines the functional option for EnableCrashDetection
+type CrashOption func(c *crashMonitor)
+
+// Recovery sets the action to execute after a crash
+func Recovery(mode RecoveryMode) CrashOption {
+	return func(c *crashMonitor) {
+		c.recovery = mode
+	}
+}
+
+// LivenessProbe enables periodic evaluation of a cheap expression in the page:
+// if the renderer doesn't reply within timeout it is considered hung.
+func LivenessProbe(interval, timeout time.Duration) CrashOption {
+	return func(c *crashMonitor) {
+		c.interval = interval
+		c.timeout = timeout
+	}
+}
+
+// OnCrash sets a typed callback, called (in a separate goroutine) when a crash is detected
+// (in addition to the EventCrashed event).
+func OnCrash(cb func(CrashEvent)) CrashOption {
+	return func(c *crashMonitor) {
+		c.onCrash = cb
+	}
+}
+
+// intentionalDetach are the Inspector.detached reasons that are not crashes
+// (i.e. the tab was closed with CloseTab, or DevTools was opened on the tab).
+var intentionalDetach = map[string]bool{
+	"target_closed":          true,
+	"replaced_with_devtools": true,
+}
+
+type crashMonitor struct {
+	remote *RemoteDebugger
+
+	recovery RecoveryMode
+	interval time.Duration
+	timeout  time.Duration
+	onCrash  func(CrashEvent)
+
+	sync.Mutex
+	handling bool      // a crash is being handled
+	last     string    // last crashed target
+	lastTime time.Time // when the last crash was reported
+	remove   []func()
+	stop     chan bool
+}
+
+// EnableCrashDetection starts tracking renderer crashes (Inspector.targetCrashed, Inspector.detached, Target.targetCrashed)
+// and, optionally, hung renderers via a liveness probe.
+//
+// When a crash is detected all pending requests fail with ErrorCrashed, the EventCrashed event is emitted and,
+// if a RecoveryMode was specified, the page is reloaded or recreated in a new tab (followed by the EventRecovered event).
+//
+// Example:
+//
+//	debugger.EnableCrashDetection(godet.Recovery(godet.RecoverNewTab),
+//	    godet.LivenessProbe(10*time.Second, 5*time.Second))
+//
+//	debugger.CallbackEvent(godet.EventCrashed, func(params godet.Params) {
+//	    log.Println("tab crashed:", params.String("reason"))
+//	})
+func (remote *RemoteDebugger) EnableCrashDetection(options ...CrashOption) error {
+	remote.DisableCrashDetection()
+
+	c := &crashMonitor{remote: remote, stop: make(chan bool)}
+	for _, o := range options {
+		o(c)
+	}
+
+	c.remove = []func(){
+		remote.addEventHandler("Inspector.targetCrashed", func(params Params) {
+			c.crashed(CrashEvent{Reason: CrashReasonCrashed})
+		}),
+		remote.addEventHandler("Inspector.detached", func(params Params) {
+			reason := params.String("reason")
+			if intentionalDetach[reason] {
+				return
+			}
+
+			c.crashed(CrashEvent{Reason: CrashReasonDetached, Detail: reason})
+		}),
+		remote.addEventHandler("Target.targetCrashed", func(params Params) {
+			c.crashed(CrashEvent{
+				TargetID:  params.String("targetId"),
+				Reason:    CrashReasonCrashed,
+				Detail:    params.String("status"),
+				ErrorCode: params.Int("errorCode"),
+			})
+		}),
+	}
+
+	remote.Lock()
+	remote.crash = c
+	remote.Unlock()
+
+	if err := remote.DomainEvents("Inspector", true); err != nil {
+		return err
+	}
+
+	if err := remote.SetDiscoverTargets(true); err != nil {
+		return err
+	}
+
+	if c.interval > 0 && c.timeout > 0 {
+		remote.handleAsync(c.probe)
+	}
+
+	return nil
+}
+
+// DisableCrashDetection stops tracking crashes and hung renderers.
+func (remote *RemoteDebugger) DisableCrashDetection() {
+	remote.Lock()
+	c := remote.crash
+	remote.crash = nil
+	remote.Unlock()
+
+	if c == nil {
+		return
+	}
+
+	for _, remove := range c.remove {
+		remove()
+	}
+
+	close(c.stop)
+}
+
+// probe periodically checks that the renderer is still responding.
+func (c *crashMonitor) probe() {
+	remote := c.remote
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+
+		case <-remote.closed:
+			return
+
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+			_, err := remote.sendRequestContext(ctx, "Runtime.evaluate", Params{
+				"expression":    "1",
+				"returnByValue": true,
+			})
+			cancel()
+
+			if err == context.DeadlineExceeded {
+				c.crashed(CrashEvent{Reason: CrashReasonHung})
+			}
+		}
+	}
+}
+
+// crashed handles a crash of the current tab.
+func (c *crashMonitor) crashed(ev CrashEvent) {
+	remote := c.remote
+
+	remote.Lock()
+	current := remote.current
+	remote.Unlock()
+
+	if ev.TargetID == "" {
+		ev.TargetID = current
+	} else if ev.TargetID != current {
+		return // some other target crashed
+	}
+
+	c.Lock()
+	if c.handling || (ev.TargetID == c.last && time.Since(c.lastTime) < time.Second) {
+		c.Unlock()
+		return // the same crash can be reported by multiple events
+	}
+	c.handling = true
+	c.last, c.lastTime = ev.TargetID, time.Now()
+	c.Unlock()
+
+	remote.failPending(ErrorCrashed)
+
+	if c.onCrash != nil {
+		remote.handleAsync(func() { c.onCrash(ev) })
+	}
+
+	remote.dispatchEvent(EventCrashed, ev.Params())
+
+	if c.recovery == NoRecovery {
+		c.done()
+		return
+	}
+
+	remote.handleAsync(func() {
+		err := c.recover(ev)
+
+		remote.Lock()
+		params := Params{"targetId": remote.current}
+		remote.Unlock()
+
+		if err != nil {
+			params["error"] = err.Error()
+		}
+
+		c.done()
+		remote.dispatchEvent(EventRecovered, params)
+	})
+}
+
+func (c *crashMonitor) done() {
+	c.Lock()
+	c.handling = false
+	c.Unlock()
+}
+
+// recover reloads the crashed page or reopens it in a new tab, according to the selected RecoveryMode.
+func (c *crashMonitor) recover(ev CrashEvent) error {
+	remote := c.remote
+
+	if c.recovery == RecoverReload && ev.Reason == CrashReasonCrashed {
+		return remote.Reload()
+	}
+
+	url := "about:blank"
+
+	if tabs, err := remote.TabList("page"); err == nil {
+		for _, t := range tabs {
+			if t.ID == ev.TargetID {
+				url = t.URL
+				break
+			}
+		}
+	}
+
+	remote.CloseTab(&Tab{ID: ev.TargetID})
+
+	if _, err := remote.NewTab(url); err != nil {
+		return err
+	}
+
+	remote.restoreDomains()
+
+	return nil
+}
@@ -0,0 +1,624 @@
+package godet
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// StyleSheet describes a stylesheet (CSS.CSSStyleSheetHeader).
+type StyleSheet struct {
+	ID          string  `json:"styleSheetId"` // Stylesheet identifier
+	FrameID     string  `json:"frameId"`      // Owner frame identifier
+	SourceURL   string  `json:"sourceURL"`    // Stylesheet resource URL (empty for inline stylesheets)
+	Origin      string  `json:"origin"`       // Stylesheet origin ("regular", "injected", "user-agent", "inspector")
+	Title       string  `json:"title"`        // Stylesheet title
+	Disabled    bool    `json:"disabled"`     // Denotes whether the stylesheet is disabled
+	IsInline    bool    `json:"isInline"`     // Whether this stylesheet is created for a STYLE tag by the parser
+	Length      float64 `json:"length"`       // Size of the content (in characters)
+	StartLine   float64 `json:"startLine"`    // Line offset of the stylesheet within the resource
+	StartColumn float64 `json:"startColumn"`  // Column offset of the stylesheet within the resource
+}
+
+// SourceRange is a text range in a stylesheet (lines and columns are 0-based).
+type SourceRange struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// CSSProperty is a property in a CSS style.
+type CSSProperty struct {
+	Name      string       `json:"name"`
+	Value     string       `json:"value"`
+	Important bool         `json:"important,omitempty"`
+	Implicit  bool         `json:"implicit,omitempty"` // property is implicitly set (i.e. a longhand from a shorthand)
+	Disabled  bool         `json:"disabled,omitempty"` // property is commented out
+	ParsedOk  bool         `json:"parsedOk"`           // property is understood by the browser
+	Text      string       `json:"text,omitempty"`     // full property text, as in the stylesheet
+	Range     *SourceRange `json:"range,omitempty"`    // property range in the stylesheet (if available)
+}
+
+// CSSStyle is a CSS style declaration (the properties of a rule or an inline style).
+type CSSStyle struct {
+	StyleSheetID string        `json:"styleSheetId,omitempty"` // empty for styles that are not editable
+	Properties   []CSSProperty `json:"cssProperties"`
+	CSSText      string        `json:"cssText,omitempty"`
+	Range        *SourceRange  `json:"range,omitempty"`
+}
+
+// CSSRule is a CSS rule.
+type CSSRule struct {
+	StyleSheetID string   `json:"styleSheetId,omitempty"`
+	Selector     string   `json:"selector"` // rule selector list (as text)
+	Selectors    []string `json:"selectors"`
+	Origin       string   `json:"origin"`
+	Style        CSSStyle `json:"style"`
+	Media        []string `json:"media,omitempty"` // media queries the rule is nested in
+}
+
+// RuleMatch is a rule matching a node, with the indices of the matching selectors.
+type RuleMatch struct {
+	Rule              CSSRule `json:"rule"`
+	MatchingSelectors []int   `json:"matchingSelectors"`
+}
+
+// InheritedStyle contains the styles inherited from an ancestor node.
+type InheritedStyle struct {
+	InlineStyle *CSSStyle   `json:"inlineStyle,omitempty"`
+	Matched     []RuleMatch `json:"matched"`
+}
+
+// PseudoElementMatches contains the rules matching a pseudo element of the node (i.e. "before", "after").
+type PseudoElementMatches struct {
+	PseudoType string      `json:"pseudoType"`
+	Matched    []RuleMatch `json:"matched"`
+}
+
+// MatchedStyles contains the styles applying to a node (see GetMatchedStylesForNode).
+type MatchedStyles struct {
+	InlineStyle     *CSSStyle              `json:"inlineStyle,omitempty"`     // style attribute
+	AttributesStyle *CSSStyle              `json:"attributesStyle,omitempty"` // styles from HTML attributes (i.e. width=100)
+	Matched         []RuleMatch            `json:"matched"`                   // matching rules, in cascade order
+	Pseudo          []PseudoElementMatches `json:"pseudo,omitempty"`
+	Inherited       []InheritedStyle       `json:"inherited,omitempty"` // from the parent up to the root
+}
+
+// cssRule is the wire format for CSS.CSSRule
+type cssRule struct {
+	StyleSheetID string `json:"styleSheetId"`
+	SelectorList struct {
+		Selectors []struct {
+			Text string `json:"text"`
+		} `json:"selectors"`
+		Text string `json:"text"`
+	} `json:"selectorList"`
+	Origin string   `json:"origin"`
+	Style  CSSStyle `json:"style"`
+	Media  []struct {
+		Text string `json:"text"`
+	} `json:"media"`
+}
+
+func (r *cssRule) rule() CSSRule {
+	rule := CSSRule{
+		StyleSheetID: r.StyleSheetID,
+		Selector:     r.SelectorList.Text,
+		Origin:       r.Origin,
+		Style:        r.Style,
+	}
+
+	for _, s := range r.SelectorList.Selectors {
+		rule.Selectors = append(rule.Selectors, s.Text)
+	}
+
+	for _, m := range r.Media {
+		rule.Media = append(rule.Media, m.Text)
+	}
+
+	return rule
+}
+
+// cssRuleMatch is the wire format for CSS.RuleMatch
+type cssRuleMatch struct {
+	Rule              cssRule `json:"rule"`
+	MatchingSelectors []int   `json:"matchingSelectors"`
+}
+
+func ruleMatches(matches []cssRuleMatch) []RuleMatch {
+	res := make([]RuleMatch, len(matches))
+	for i, m := range matches {
+		res[i] = RuleMatch{Rule: m.Rule.rule(), MatchingSelectors: m.MatchingSelectors}
+	}
+
+	return res
+}
+
+// GetMatchedStylesForNode returns the styles applying to a DOM node identified by nodeId:
+// inline and attribute styles, matching rules, pseudo elements rules and inherited styles.
+// Note that the CSS domain must be enabled (see CSSEvents).
+//
+// Example:
+//
+//	styles, err := debugger.GetMatchedStylesForNode(nodeID)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	for _, m := range styles.Matched {
+//	    fmt.Println(m.Rule.Selector, m.Rule.Style.CSSText)
+//	}
+func (remote *RemoteDebugger) GetMatchedStylesForNode(nodeID int) (*MatchedStyles, error) {
+	rawReply, err := remote.sendRawReplyRequest("CSS.getMatchedStylesForNode", Params{
+		"nodeId": nodeID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		InlineStyle     *CSSStyle      `json:"inlineStyle"`
+		AttributesStyle *CSSStyle      `json:"attributesStyle"`
+		MatchedRules    []cssRuleMatch `json:"matchedCSSRules"`
+		Pseudo          []struct {
+			PseudoType string         `json:"pseudoType"`
+			Matches    []cssRuleMatch `json:"matches"`
+		} `json:"pseudoElements"`
+		Inherited []struct {
+			InlineStyle  *CSSStyle      `json:"inlineStyle"`
+			MatchedRules []cssRuleMatch `json:"matchedCSSRules"`
+		} `json:"inherited"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	styles := &MatchedStyles{
+		InlineStyle:     res.InlineStyle,
+		AttributesStyle: res.AttributesStyle,
+		Matched:         ruleMatches(res.MatchedRules),
+	}
+
+	for _, p := range res.Pseudo {
+		styles.Pseudo = append(styles.Pseudo, PseudoElementMatches{PseudoType: p.PseudoType, Matched: ruleMatches(p.Matches)})
+	}
+
+	for _, i := range res.Inherited {
+		styles.Inherited = append(styles.Inherited, InheritedStyle{InlineStyle: i.InlineStyle, Matched: ruleMatches(i.MatchedRules)})
+	}
+
+	return styles, nil
+}
+
+// StyleEdit is an edit for SetStyleTexts: the text replaces the style declaration in the specified range.
+type StyleEdit struct {
+	StyleSheetID string      `json:"styleSheetId"`
+	Range        SourceRange `json:"range"`
+	Text         string      `json:"text"`
+}
+
+// SetStyleTexts applies the edits to the style declarations and returns the updated styles.
+// The style ranges are returned by GetMatchedStylesForNode (CSSStyle.Range).
+func (remote *RemoteDebugger) SetStyleTexts(edits ...StyleEdit) ([]CSSStyle, error) {
+	rawReply, err := remote.sendRawReplyRequest("CSS.setStyleTexts", Params{
+		"edits": edits,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		Styles []CSSStyle `json:"styles"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Styles, nil
+}
+
+// SetInlineStyle replaces the inline style (the style attribute) of a DOM node identified by nodeId
+// and returns the updated style. If the node has no style attribute, the attribute is set and no style is returned.
+//
+// Example:
+//
+//	debugger.SetInlineStyle(nodeID, "color: red; border: 1px solid")
+func (remote *RemoteDebugger) SetInlineStyle(nodeID int, text string) (*CSSStyle, error) {
+	rawReply, err := remote.sendRawReplyRequest("CSS.getInlineStylesForNode", Params{
+		"nodeId": nodeID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		InlineStyle *CSSStyle `json:"inlineStyle"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	if res.InlineStyle == nil || res.InlineStyle.StyleSheetID == "" || res.InlineStyle.Range == nil {
+		// no style attribute yet
+		if err := remote.SetAttributeValue(nodeID, "style", text); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	styles, err := remote.SetStyleTexts(StyleEdit{
+		StyleSheetID: res.InlineStyle.StyleSheetID,
+		Range:        *res.InlineStyle.Range,
+		Text:         text,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(styles) == 0 {
+		return nil, ErrorNoResponse
+	}
+
+	return &styles[0], nil
+}
+
+// CreateStyleSheet creates a new empty "inspector" stylesheet in the specified frame (see AddRule)
+// and returns its id.
+func (remote *RemoteDebugger) CreateStyleSheet(frameID string) (string, error) {
+	res, err := remote.SendRequest("CSS.createStyleSheet", Params{
+		"frameId": frameID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if res == nil {
+		return "", ErrorNoResponse
+	}
+
+	id, _ := res["styleSheetId"].(string)
+	return id, nil
+}
+
+// GetStyleSheetText returns the text of the stylesheet.
+func (remote *RemoteDebugger) GetStyleSheetText(styleSheetID string) (string, error) {
+	res, err := remote.SendRequest("CSS.getStyleSheetText", Params{
+		"styleSheetId": styleSheetID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if res == nil {
+		return "", ErrorNoResponse
+	}
+
+	text, _ := res["text"].(string)
+	return text, nil
+}
+
+// SetStyleSheetText replaces the text of the stylesheet.
+func (remote *RemoteDebugger) SetStyleSheetText(styleSheetID, text string) error {
+	_, err := remote.SendRequest("CSS.setStyleSheetText", Params{
+		"styleSheetId": styleSheetID,
+		"text":         text,
+	})
+	return err
+}
+
+// AddRule appends a rule (i.e. `div.note { color: red }`) to the stylesheet and returns the new rule.
+//
+// Example:
+//
+//	tree, _ := debugger.NewFrameTree()
+//	sheet, err := debugger.CreateStyleSheet(tree.MainFrame().ID)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	debugger.AddRule(sheet, "* { animation: none !important }")
+func (remote *RemoteDebugger) AddRule(styleSheetID, ruleText string) (*CSSRule, error) {
+	text, err := remote.GetStyleSheetText(styleSheetID)
+	if err != nil {
+		return nil, err
+	}
+
+	// insert at the end of the stylesheet (columns are in UTF-16 code units)
+	line := strings.Count(text, "\n")
+	column := len(utf16.Encode([]rune(text[strings.LastIndex(text, "\n")+1:])))
+
+	rawReply, err := remote.sendRawReplyRequest("CSS.addRule", Params{
+		"styleSheetId": styleSheetID,
+		"ruleText":     ruleText,
+		"location": SourceRange{
+			StartLine:   line,
+			StartColumn: column,
+			EndLine:     line,
+			EndColumn:   column,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		Rule cssRule `json:"rule"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	rule := res.Rule.rule()
+	return &rule, nil
+}
+
+func (remote *RemoteDebugger) onStyleSheetAdded(params Params) {
+	b, err := json.Marshal(params["header"])
+	if err != nil {
+		return
+	}
+
+	var sheet StyleSheet
+	if json.Unmarshal(b, &sheet) != nil || sheet.ID == "" {
+		return
+	}
+
+	remote.Lock()
+	defer remote.Unlock()
+
+	// enabling the CSS domain again reports the existing stylesheets
+	for i, s := range remote.styleSheets {
+		if s.ID == sheet.ID {
+			remote.styleSheets[i] = sheet
+			return
+		}
+	}
+
+	remote.styleSheets = append(remote.styleSheets, sheet)
+}
+
+// onFrameNavigated clears the tracked stylesheets when the main frame navigates to a new document.
+func (remote *RemoteDebugger) onFrameNavigated(params Params) {
+	if frame := params.Map("frame"); frame != nil && frame["parentId"] == nil {
+		remote.Lock()
+		remote.styleSheets = nil
+		remote.Unlock()
+	}
+}
+
+func (remote *RemoteDebugger) onStyleSheetRemoved(params Params) {
+	id := params.String("styleSheetId")
+
+	remote.Lock()
+	defer remote.Unlock()
+
+	for i, sheet := range remote.styleSheets {
+		if sheet.ID == id {
+			remote.styleSheets = append(remote.styleSheets[:i:i], remote.styleSheets[i+1:]...)
+			break
+		}
+	}
+}
+
+// StyleSheets returns the stylesheets in the page, in the order they were added.
+// Stylesheets are tracked while CSS events are enabled (see CSSEvents); enabling
+// the CSS domain reports all the existing stylesheets.
+// The list is cleared when switching to another tab and, if Page events are enabled, when the main frame navigates.
+func (remote *RemoteDebugger) StyleSheets() []StyleSheet {
+	remote.Lock()
+	defer remote.Unlock()
+
+	return append([]StyleSheet(nil), remote.styleSheets...)
+}
+
+// styleSheet returns the tracked stylesheet with the specified id.
+func (remote *RemoteDebugger) styleSheet(id string) (StyleSheet, bool) {
+	remote.Lock()
+	defer remote.Unlock()
+
+	for _, sheet := range remote.styleSheets {
+		if sheet.ID == id {
+			return sheet, true
+		}
+	}
+
+	return StyleSheet{}, false
+}
+
+// RuleUsage reports if a CSS rule was used (the offsets are the rule range in the stylesheet text, in UTF-16 code units).
+type RuleUsage struct {
+	StyleSheetID string  `json:"styleSheetId"`
+	StartOffset  float64 `json:"startOffset"`
+	EndOffset    float64 `json:"endOffset"`
+	Used         bool    `json:"used"`
+}
+
+func decodeRuleUsage(rawReply []byte) ([]RuleUsage, error) {
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		RuleUsage []RuleUsage `json:"ruleUsage"`
+		Coverage  []RuleUsage `json:"coverage"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	if res.Coverage != nil {
+		return res.Coverage, nil
+	}
+
+	return res.RuleUsage, nil
+}
+
+// StartRuleUsageTracking starts tracking the usage of CSS rules (see TakeCoverageDelta and StopRuleUsageTracking).
+// Note that the CSS domain must be enabled (see CSSEvents).
+func (remote *RemoteDebugger) StartRuleUsageTracking() error {
+	_, err := remote.SendRequest("CSS.startRuleUsageTracking", nil)
+	return err
+}
+
+// TakeCoverageDelta returns the rules usage since the previous call (or the start of the tracking).
+func (remote *RemoteDebugger) TakeCoverageDelta() ([]RuleUsage, error) {
+	rawReply, err := remote.sendRawReplyRequest("CSS.takeCoverageDelta", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRuleUsage(rawReply)
+}
+
+// StopRuleUsageTracking stops tracking the usage of CSS rules and returns the rules usage since the last delta.
+func (remote *RemoteDebugger) StopRuleUsageTracking() ([]RuleUsage, error) {
+	rawReply, err := remote.sendRawReplyRequest("CSS.stopRuleUsageTracking", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRuleUsage(rawReply)
+}
+
+// CSSCoverage reports the used and unused bytes of a stylesheet (see UnusedCSS).
+// The sizes are the UTF-8 bytes of the stylesheet text (the rule offsets reported by the browser are in UTF-16 code units).
+type CSSCoverage struct {
+	StyleSheet  StyleSheet  `json:"styleSheet"`
+	TotalBytes  int         `json:"totalBytes"`
+	UsedBytes   int         `json:"usedBytes"`
+	UnusedBytes int         `json:"unusedBytes"` // bytes in unused rules
+	UnusedRules []RuleUsage `json:"unusedRules"`
+}
+
+// utf16ByteOffsets returns the byte offset in text for each UTF-16 offset (including the end of the text).
+func utf16ByteOffsets(text string) []int {
+	offsets := make([]int, 0, len(text)+1)
+
+	for i, r := range text {
+		offsets = append(offsets, i)
+		if r >= 0x10000 { // surrogate pair
+			offsets = append(offsets, i)
+		}
+	}
+
+	return append(offsets, len(text))
+}
+
+// UnusedCSS aggregates the rules usage returned by TakeCoverageDelta and StopRuleUsageTracking (possibly from multiple calls)
+// and returns the coverage for each stylesheet, sorted by unused bytes (the largest first).
+// A rule is considered used if it was reported used at least once.
+//
+// The text of the stylesheets is requested to count the bytes, so the stylesheets should still be in the page.
+//
+// Example:
+//
+//	debugger.CSSEvents(true)
+//	debugger.StartRuleUsageTracking()
+//	debugger.Navigate("https://www.example.com")
+//	...
+//	usage, _ := debugger.StopRuleUsageTracking()
+//
+//	coverage, err := debugger.UnusedCSS(usage)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	for _, c := range coverage {
+//	    fmt.Printf("%s: %d/%d bytes unused\n", c.StyleSheet.SourceURL, c.UnusedBytes, c.TotalBytes)
+//	}
+func (remote *RemoteDebugger) UnusedCSS(usage []RuleUsage) ([]CSSCoverage, error) {
+	type ruleKey struct {
+		start, end float64
+	}
+
+	rules := map[string]map[ruleKey]bool{}
+	for _, u := range usage {
+		if rules[u.StyleSheetID] == nil {
+			rules[u.StyleSheetID] = map[ruleKey]bool{}
+		}
+
+		k := ruleKey{u.StartOffset, u.EndOffset}
+		rules[u.StyleSheetID][k] = rules[u.StyleSheetID][k] || u.Used
+	}
+
+	var coverage []CSSCoverage
+
+	for id, sheetRules := range rules {
+		sheet, ok := remote.styleSheet(id)
+		if !ok {
+			sheet = StyleSheet{ID: id}
+		}
+
+		text, err := remote.GetStyleSheetText(id)
+		if err != nil {
+			return nil, err
+		}
+
+		offsets := utf16ByteOffsets(text)
+
+		// byteOffset converts a rule offset to a byte offset in the text
+		byteOffset := func(offset float64) int {
+			i := int(offset)
+			if i < 0 {
+				i = 0
+			} else if i >= len(offsets) {
+				i = len(offsets) - 1
+			}
+
+			return offsets[i]
+		}
+
+		c := CSSCoverage{StyleSheet: sheet, TotalBytes: len(text)}
+
+		for k, used := range sheetRules {
+			size := byteOffset(k.end) - byteOffset(k.start)
+
+			if used {
+				c.UsedBytes += size
+			} else {
+				c.UnusedBytes += size
+				c.UnusedRules = append(c.UnusedRules, RuleUsage{StyleSheetID: id, StartOffset: k.start, EndOffset: k.end})
+			}
+		}
+
+		sort.Slice(c.UnusedRules, func(i, j int) bool {
+			return c.UnusedRules[i].StartOffset < c.UnusedRules[j].StartOffset
+		})
+
+		coverage = append(coverage, c)
+	}
+
+	sort.Slice(coverage, func(i, j int) bool {
+		if coverage[i].UnusedBytes != coverage[j].UnusedBytes {
+			return coverage[i].UnusedBytes > coverage[j].UnusedBytes
+		}
+
+		return coverage[i].StyleSheet.ID < coverage[j].StyleSheet.ID
+	})
+
+	return coverage, nil
+}
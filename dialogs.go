@@ -0,0 +1,131 @@
+package godet
+
+import (
+	"log"
+	"time"
+)
+
+// DialogType defines the type of a JavaScript dialog
+type DialogType string
+
+const (
+	// DialogAlert is a window.alert dialog
+	DialogAlert = DialogType("alert")
+	// DialogConfirm is a window.confirm dialog
+	DialogConfirm = DialogType("confirm")
+	// DialogPrompt is a window.prompt dialog
+	DialogPrompt = DialogType("prompt")
+	// DialogBeforeUnload is the dialog shown when leaving a page with a beforeunload handler
+	DialogBeforeUnload = DialogType("beforeunload")
+)
+
+// Dialog contains information about a JavaScript dialog opened by the page, and how it was handled.
+type Dialog struct {
+	Type          DialogType // alert, confirm, prompt or beforeunload
+	Message       string     // message displayed by the dialog
+	URL           string     // URL of the frame that opened the dialog
+	DefaultPrompt string     // default value for prompt dialogs
+	Time          time.Time  // when the dialog was opened
+
+	Accepted   bool   // true if the dialog was accepted
+	PromptText string // the text entered for prompt dialogs
+}
+
+// DialogPolicy decides how a JavaScript dialog should be handled.
+// It returns true to accept the dialog (false to dismiss it) and the text to enter for prompt dialogs.
+//
+// For beforeunload dialogs, accepting the dialog leaves the page (and the navigation proceeds),
+// dismissing the dialog stays on the page.
+type DialogPolicy func(d Dialog) (accept bool, promptText string)
+
+var (
+	// AcceptDialogs accepts all dialogs, using the default prompt for prompt dialogs
+	AcceptDialogs DialogPolicy = func(d Dialog) (bool, string) {
+		return true, d.DefaultPrompt
+	}
+
+	// DismissDialogs dismisses all dialogs
+	DismissDialogs DialogPolicy = func(d Dialog) (bool, string) {
+		return false, ""
+	}
+)
+
+// AnswerPrompts accepts all dialogs and answers prompt dialogs with the specified text
+func AnswerPrompts(text string) DialogPolicy {
+	return func(d Dialog) (bool, string) {
+		return true, text
+	}
+}
+
+// SetDialogPolicy handles JavaScript dialogs (Page.javascriptDialogOpening) according to the specified policy,
+// so that dialogs opened by the page don't block further evaluation.
+// Use a nil policy to stop handling dialogs. Note that this enables Page events.
+//
+// Since the policy handles beforeunload dialogs too, Navigate, Reload, ClosePage and CloseTab with the RunBeforeUnload option
+// are not blocked by pages with beforeunload handlers.
+//
+// All handled dialogs are recorded and can be retrieved with DialogLog.
+//
+// Example:
+//
+//	debugger.SetDialogPolicy(func(d godet.Dialog) (bool, string) {
+//	    log.Println("dialog", d.Type, d.Message)
+//	    return d.Type != godet.DialogConfirm, "godet"
+//	})
+func (remote *RemoteDebugger) SetDialogPolicy(policy DialogPolicy) error {
+	remote.Lock()
+	remove := remote.dialogPolicy
+	remote.dialogPolicy = nil
+	remote.Unlock()
+
+	if remove != nil {
+		remove()
+	}
+
+	if policy == nil {
+		return nil
+	}
+
+	remove = remote.addEventHandler("Page.javascriptDialogOpening", func(params Params) {
+		d := Dialog{
+			Type:          DialogType(params.String("type")),
+			Message:       params.String("message"),
+			URL:           params.String("url"),
+			DefaultPrompt: params.String("defaultPrompt"),
+			Time:          time.Now(),
+		}
+
+		remote.handleAsync(func() {
+			d.Accepted, d.PromptText = policy(d)
+
+			remote.Lock()
+			remote.dialogLog = append(remote.dialogLog, d)
+			remote.Unlock()
+
+			if err := remote.HandleJavaScriptDialog(d.Accepted, d.PromptText); err != nil && remote.verbose {
+				log.Println("handle dialog:", err)
+			}
+		})
+	})
+
+	remote.Lock()
+	remote.dialogPolicy = remove
+	remote.Unlock()
+
+	return remote.PageEvents(true)
+}
+
+// DialogLog returns the list of dialogs handled by the dialog policy.
+func (remote *RemoteDebugger) DialogLog() []Dialog {
+	remote.Lock()
+	defer remote.Unlock()
+
+	return append([]Dialog(nil), remote.dialogLog...)
+}
+
+// ClearDialogLog clears the list of dialogs handled by the dialog policy.
+func (remote *RemoteDebugger) ClearDialogLog() {
+	remote.Lock()
+	remote.dialogLog = nil
+	remote.Unlock()
+}
@@ -0,0 +1,552 @@
+package godet
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+)
+
+// Element is a handle to a DOM element.
+//
+// The element is identified by its backend node id, that is stable for the lifetime of the node.
+// The node id (used by most DOM methods) and the JavaScript object id are resolved when needed,
+// so that the element is still usable after the node ids have been invalidated
+// (i.e. by DOM.documentUpdated or GetDocument).
+//
+// Example:
+//
+//	input, err := debugger.QueryElement("input[name=q]")
+//	if err != nil || input == nil {
+//	    log.Fatal("no input", err)
+//	}
+//
+//	input.Type("godet")
+//
+//	button, _ := debugger.QueryElement("button[type=submit]")
+//	button.Click()
+type Element struct {
+	BackendNodeID int // Backend node identifier
+
+	remote *RemoteDebugger
+
+	sync.Mutex
+	nodeID int // node id, valid for the DOM generation gen
+	gen    int
+}
+
+// QueryOption defines the functional options for element queries
+type QueryOption func(q *queryOptions)
+
+type queryOptions struct {
+	deep   bool // traverse open shadow roots
+	closed bool // traverse closed shadow roots
+}
+
+// PierceShadow makes element queries traverse open shadow roots.
+func PierceShadow() QueryOption {
+	return func(q *queryOptions) {
+		q.deep = true
+	}
+}
+
+// PierceClosedShadow makes element queries traverse open and closed shadow roots.
+// Closed shadow roots are found with DOM.describeNode, so this is slower than PierceShadow.
+func PierceClosedShadow() QueryOption {
+	return func(q *queryOptions) {
+		q.deep = true
+		q.closed = true
+	}
+}
+
+// describeNode returns the node description (DOM.describeNode) for the node identified by the params.
+func (remote *RemoteDebugger) describeNode(params Params) (map[string]interface{}, error) {
+	res, err := remote.SendRequest("DOM.describeNode", params)
+	if err != nil {
+		return nil, err
+	}
+
+	node, _ := res["node"].(map[string]interface{})
+	if node == nil {
+		return nil, ErrorNoResponse
+	}
+
+	return node, nil
+}
+
+// newElement returns an element from the node description.
+func (remote *RemoteDebugger) newElement(node map[string]interface{}, nodeID, gen int) (*Element, error) {
+	backendID, _ := node["backendNodeId"].(float64)
+	if backendID == 0 {
+		return nil, ErrorNoResponse
+	}
+
+	return &Element{BackendNodeID: int(backendID), remote: remote, nodeID: nodeID, gen: gen}, nil
+}
+
+// ElementForNode returns the element for the specified node id.
+func (remote *RemoteDebugger) ElementForNode(nodeID int) (*Element, error) {
+	gen := remote.domGeneration()
+
+	node, err := remote.describeNode(Params{"nodeId": nodeID})
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.newElement(node, nodeID, gen)
+}
+
+// ElementForBackendNode returns the element for the specified backend node id.
+func (remote *RemoteDebugger) ElementForBackendNode(backendNodeID int) *Element {
+	return &Element{BackendNodeID: backendNodeID, remote: remote}
+}
+
+// ElementForObject returns the element for the node referenced by the JavaScript object id.
+func (remote *RemoteDebugger) ElementForObject(objectID string) (*Element, error) {
+	node, err := remote.describeNode(Params{"objectId": objectID})
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.newElement(node, 0, 0)
+}
+
+// DocumentElement returns the element for the current document.
+// Contrary to GetDocument, this doesn't invalidate the existing node ids.
+func (remote *RemoteDebugger) DocumentElement() (*Element, error) {
+	res, err := remote.evaluateObject(context.Background(), Params{"expression": "document"})
+	if err != nil {
+		return nil, err
+	}
+
+	objectID, _ := res["objectId"].(string)
+	if objectID == "" {
+		return nil, ErrorNoResponse
+	}
+
+	defer remote.ReleaseObject(objectID)
+	return remote.ElementForObject(objectID)
+}
+
+// QueryElement returns the first element in the document matching the selector, or nil if there is no match.
+func (remote *RemoteDebugger) QueryElement(selector string, options ...QueryOption) (*Element, error) {
+	doc, err := remote.DocumentElement()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.QuerySelector(selector, options...)
+}
+
+// QueryElements returns all the elements in the document matching the selector.
+func (remote *RemoteDebugger) QueryElements(selector string, options ...QueryOption) ([]*Element, error) {
+	doc, err := remote.DocumentElement()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.QuerySelectorAll(selector, options...)
+}
+
+// NodeID returns the node id for the element.
+// If the node ids have been invalidated since the element was resolved, the node id is resolved again.
+func (elem *Element) NodeID() (int, error) {
+	remote := elem.remote
+
+	elem.Lock()
+	defer elem.Unlock()
+
+	for retry := 0; retry < 2; retry++ {
+		gen := remote.domGeneration()
+		if elem.nodeID != 0 && elem.gen == gen {
+			return elem.nodeID, nil
+		}
+
+		res, err := remote.SendRequest("DOM.pushNodesByBackendIdsToFrontend", Params{
+			"backendNodeIds": []int{elem.BackendNodeID},
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		ids, _ := res["nodeIds"].([]interface{})
+		if len(ids) == 1 {
+			if id, _ := ids[0].(float64); id != 0 {
+				elem.nodeID, elem.gen = int(id), gen
+				return elem.nodeID, nil
+			}
+		}
+
+		// the document needs to be requested before nodes can be pushed to the client
+		if _, err := remote.GetDocument(); err != nil {
+			return 0, err
+		}
+	}
+
+	return 0, ErrorNodeDetached
+}
+
+// ObjectID returns a new JavaScript object id for the element, in the default execution context.
+// The object should be released with ReleaseObject when not needed anymore.
+func (elem *Element) ObjectID() (string, error) {
+	res, err := elem.remote.SendRequest("DOM.resolveNode", Params{
+		"backendNodeId": elem.BackendNodeID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	object, _ := res["object"].(map[string]interface{})
+	objectID, _ := object["objectId"].(string)
+	if objectID == "" {
+		return "", ErrorNodeDetached
+	}
+
+	return objectID, nil
+}
+
+// callFunction calls the JavaScript function with the element as `this` and returns the result value.
+func (elem *Element) callFunction(fn string, args ...interface{}) (interface{}, error) {
+	remote := elem.remote
+
+	objectID, err := elem.ObjectID()
+	if err != nil {
+		return nil, err
+	}
+
+	defer remote.ReleaseObject(objectID)
+
+	res, err := remote.callFunctionOn(objectID, fn, true, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return res["value"], nil
+}
+
+// QuerySelector returns the first descendant of the element matching the selector, or nil if there is no match.
+// See RemoteDebugger.QuerySelector for the selector syntax.
+//
+// With the PierceShadow or PierceClosedShadow options the query also searches the element shadow roots.
+func (elem *Element) QuerySelector(selector string, options ...QueryOption) (*Element, error) {
+	if q := newQueryOptions(options); q.deep {
+		elements, err := elem.queryDeep(selector, false, q)
+		if len(elements) == 0 {
+			return nil, err
+		}
+
+		return elements[0], err
+	}
+
+	nodeID, err := elem.NodeID()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := elem.remote.QuerySelector(nodeID, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	id, _ := res["nodeId"].(float64)
+	if id == 0 {
+		return nil, nil
+	}
+
+	return elem.remote.ElementForNode(int(id))
+}
+
+// QuerySelectorAll returns all the descendants of the element matching the selector.
+//
+// With the PierceShadow or PierceClosedShadow options the query also searches the element shadow roots.
+// In this case the elements are grouped by shadow root, so they may not be in document order.
+func (elem *Element) QuerySelectorAll(selector string, options ...QueryOption) ([]*Element, error) {
+	if q := newQueryOptions(options); q.deep {
+		return elem.queryDeep(selector, true, q)
+	}
+
+	nodeID, err := elem.NodeID()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := elem.remote.QuerySelectorAll(nodeID, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, _ := res["nodeIds"].([]interface{})
+	elements := make([]*Element, 0, len(ids))
+
+	for _, id := range ids {
+		e, err := elem.remote.ElementForNode(int(id.(float64)))
+		if err != nil {
+			return nil, err
+		}
+
+		elements = append(elements, e)
+	}
+
+	return elements, nil
+}
+
+func newQueryOptions(options []QueryOption) (q queryOptions) {
+	for _, o := range options {
+		o(&q)
+	}
+
+	return
+}
+
+// queryDeep runs the selector query in the page, traversing the open shadow roots
+// (and the closed shadow roots, if requested).
+func (elem *Element) queryDeep(selector string, all bool, q queryOptions) ([]*Element, error) {
+	remote := elem.remote
+
+	group := newQueryGroup()
+	defer remote.ReleaseObjectGroup(group)
+
+	roots := []int{elem.BackendNodeID}
+
+	if q.closed {
+		node, err := remote.describeNode(Params{
+			"backendNodeId": elem.BackendNodeID,
+			"depth":         -1,
+			"pierce":        true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		roots = append(roots, closedShadowRoots(node)...)
+	}
+
+	engine, value := parseSelector(selector)
+
+	var elements []*Element
+
+	for _, root := range roots {
+		objectID, err := remote.resolveBackendNode(root, group)
+		if err != nil {
+			return nil, err
+		}
+
+		err = remote.queryObjects(objectID, engine, value, all, true, func(oid string) error {
+			e, err := remote.ElementForObject(oid)
+			if err == nil {
+				elements = append(elements, e)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !all && len(elements) > 0 {
+			break
+		}
+	}
+
+	return elements, nil
+}
+
+// Focus sets the focus on the element.
+func (elem *Element) Focus() error {
+	_, err := elem.remote.SendRequest("DOM.focus", Params{
+		"backendNodeId": elem.BackendNodeID,
+	})
+
+	return err
+}
+
+// ScrollIntoView scrolls the element into view, if it's not already visible.
+func (elem *Element) ScrollIntoView() error {
+	_, err := elem.remote.SendRequest("DOM.scrollIntoViewIfNeeded", Params{
+		"backendNodeId": elem.BackendNodeID,
+	})
+
+	return err
+}
+
+// BoxModel returns the element boxes (see GetBoxModel).
+func (elem *Element) BoxModel() (*BoxModel, error) {
+	rawReply, err := elem.remote.sendRawReplyRequest("DOM.getBoxModel", Params{
+		"backendNodeId": elem.BackendNodeID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeBoxModel(rawReply)
+}
+
+// ContentQuads returns the quads describing the element (see GetContentQuads).
+func (elem *Element) ContentQuads() ([]Quad, error) {
+	rawReply, err := elem.remote.sendRawReplyRequest("DOM.getContentQuads", Params{
+		"backendNodeId": elem.BackendNodeID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeQuads(rawReply)
+}
+
+// ClickablePoint returns the center of the largest part of the element that is visible in the viewport
+// (for inline elements split across lines, the center of the largest visible line box).
+// If no part of the element is visible ErrorNotVisible is returned.
+func (elem *Element) ClickablePoint() (Point, error) {
+	quads, err := elem.ContentQuads()
+	if err != nil {
+		return Point{}, err
+	}
+
+	viewport, err := elem.remote.GetViewport()
+	if err != nil {
+		return Point{}, err
+	}
+
+	p, ok := clickablePoint(quads, viewport)
+	if !ok {
+		return Point{}, ErrorNotVisible
+	}
+
+	return p, nil
+}
+
+// Click scrolls the element into view and clicks (with the left button) in the center of the element
+// (see ClickablePoint).
+func (elem *Element) Click(options ...MouseOption) error {
+	return elem.remote.Click(elem, options...)
+}
+
+// DoubleClick scrolls the element into view and double clicks in the center of the element.
+func (elem *Element) DoubleClick(options ...MouseOption) error {
+	return elem.remote.DoubleClick(elem, options...)
+}
+
+// Hover scrolls the element into view and moves the mouse over the center of the element.
+func (elem *Element) Hover() error {
+	return elem.remote.Hover(elem)
+}
+
+// Tap scrolls the element into view and taps in the center of the element (see RemoteDebugger.Tap).
+func (elem *Element) Tap() error {
+	return elem.remote.Tap(elem)
+}
+
+// Type focuses the element and sends the text as keyboard input (see TypeText).
+func (elem *Element) Type(text string) error {
+	if err := elem.Focus(); err != nil {
+		return err
+	}
+
+	return elem.remote.TypeText(text, 0)
+}
+
+// Text returns the rendered text of the element (innerText, or textContent for non-HTML elements).
+func (elem *Element) Text() (string, error) {
+	v, err := elem.callFunction(`function() { return this.innerText !== undefined ? this.innerText : this.textContent; }`)
+	s, _ := v.(string)
+	return s, err
+}
+
+// InnerHTML returns the HTML markup of the element content.
+func (elem *Element) InnerHTML() (string, error) {
+	v, err := elem.callFunction(`function() { return this.innerHTML; }`)
+	s, _ := v.(string)
+	return s, err
+}
+
+// OuterHTML returns the HTML markup of the element.
+func (elem *Element) OuterHTML() (string, error) {
+	res, err := elem.remote.SendRequest("DOM.getOuterHTML", Params{
+		"backendNodeId": elem.BackendNodeID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s, _ := res["outerHTML"].(string)
+	return s, nil
+}
+
+// Attr returns the value of the specified attribute (or an empty string if the element doesn't have the attribute).
+func (elem *Element) Attr(name string) (string, error) {
+	v, err := elem.callFunction(`function(name) { return this.getAttribute(name); }`, name)
+	s, _ := v.(string)
+	return s, err
+}
+
+// SetAttr sets the value of the specified attribute.
+func (elem *Element) SetAttr(name, value string) error {
+	nodeID, err := elem.NodeID()
+	if err != nil {
+		return err
+	}
+
+	return elem.remote.SetAttributeValue(nodeID, name, value)
+}
+
+// SetFiles sets the files for a file input element.
+func (elem *Element) SetFiles(files ...string) error {
+	return elem.remote.SetFileInputFiles(elem.BackendNodeID, files, BackendNodeId)
+}
+
+// Screenshot scrolls the element into view and takes a screenshot of the element area.
+// The format can be "png" (the default) or "jpeg", the quality (0-100) is only used for JPEG.
+func (elem *Element) Screenshot(format string, quality int) ([]byte, error) {
+	if format == "" {
+		format = "png"
+	}
+
+	if err := elem.ScrollIntoView(); err != nil {
+		return nil, err
+	}
+
+	model, err := elem.BoxModel()
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := model.Bounds()
+
+	// box model coordinates are relative to the viewport, clip coordinates are relative to the page
+	rawReply, err := elem.remote.sendRawReplyRequest("Page.getLayoutMetrics", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics struct {
+		Viewport struct {
+			PageX float64 `json:"pageX"`
+			PageY float64 `json:"pageY"`
+		} `json:"cssVisualViewport"`
+	}
+
+	if rawReply != nil {
+		json.Unmarshal(rawReply, &metrics)
+	}
+
+	res, err := elem.remote.SendRequest("Page.captureScreenshot", Params{
+		"format":  format,
+		"quality": quality,
+		"clip": Params{
+			"x":      bounds.X + metrics.Viewport.PageX,
+			"y":      bounds.Y + metrics.Viewport.PageY,
+			"width":  bounds.Width,
+			"height": bounds.Height,
+			"scale":  1,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, _ := res["data"].(string)
+	if data == "" {
+		return nil, ErrorNoResponse
+	}
+
+	return base64.StdEncoding.DecodeString(data)
+}
@@ -54,9 +54,13 @@ func main() {
 
 	remote.SetVisibleSize(375, 667)                           // iPhone 7
 	remote.SetDeviceMetricsOverride(375, 667, 3, true, false) // iPhone 7
+	remote.SetTouchEmulation(true, 5)
 
 	time.Sleep(time.Second * 3)
 
+	// scroll down with a swipe up
+	remote.ScrollGesture(187, 500, 0, -300, godet.GestureSource("touch"))
+
 	// take a screenshot
 	remote.SaveScreenshot("mobile.png", 0644, 0, true)
 }
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/raff/godet"
 	"sync"
@@ -55,32 +56,39 @@ func processPage(id int, url string) {
 	fmt.Println(id, "connected")
 	defer remote.Close()
 
-	done := make(chan bool)
-
 	//
-	// this should wait until the page request has loaded (if the page has multiple frames there
-	// may be more "frameStoppedLoading" events and the check should be more complicated)
+	// each job runs in its own browser context, so that cookies, storage and cache are not shared
 	//
-	remote.CallbackEvent("Page.frameStoppedLoading", func(params godet.Params) {
-		fmt.Println(id, "page loaded", params)
-		done <- true
-	})
-
-	tab, err := remote.NewTab(url)
+	bctx, err := remote.NewBrowserContext()
 	if err != nil {
-		fmt.Println(id, "cannot create tab:", err)
+		fmt.Println(id, "cannot create browser context:", err)
 		return
 	}
 
 	defer func() {
-		remote.CloseTab(tab)
+		bctx.Dispose()
 		fmt.Println(id, "done")
 	}()
 
-	// events needs to be associated to current tab (enable AFTER NewTab)
-	remote.PageEvents(true)
+	_, err = bctx.NewTab("about:blank")
+	if err != nil {
+		fmt.Println(id, "cannot create tab:", err)
+		return
+	}
+
+	//
+	// navigate and wait until the main frame has loaded
+	//
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	finalURL, status, err := remote.NavigateAndWait(ctx, url, godet.UntilLoad)
+	if err != nil {
+		fmt.Println(id, "cannot load page:", err)
+		return
+	}
 
-	_ = <-done
+	fmt.Println(id, "page loaded", finalURL, status)
 
 	// here the page should be ready
 	// add code to process content or take screenshot
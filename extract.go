@@ -0,0 +1,412 @@
+package godet
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExtractError is returned by Extract if the destination type is not supported or a value cannot be converted.
+type ExtractError string
+
+// Error implements the error interface for ExtractError.
+func (err ExtractError) Error() string {
+	return "ExtractError:" + string(err)
+}
+
+// extractSpec describes what to extract for a value (a field, the elements of a slice or the root value).
+type extractSpec struct {
+	Engine string         `json:"engine,omitempty"`
+	Sel    string         `json:"sel,omitempty"`
+	Mode   string         `json:"mode"` // text, html or attr
+	Attr   string         `json:"attr,omitempty"`
+	All    bool           `json:"all"`
+	Fields []*extractSpec `json:"fields"`
+
+	index  []int  // field index in the parent struct
+	name   string // field name, for error messages
+	layout string // time layout
+}
+
+// extractTagKeys are the keys recognized in the godet struct tag
+var extractTagKeys = []string{"css", "xpath", "text", "html", "attr", "layout"}
+
+// splitExtractTag splits the tag on the commas that are followed by a known key,
+// so that selectors and time layouts can contain commas.
+func splitExtractTag(tag string) []string {
+	var parts []string
+
+	start := 0
+	for i := 0; i < len(tag); i++ {
+		if tag[i] != ',' {
+			continue
+		}
+
+		for _, k := range extractTagKeys {
+			if strings.HasPrefix(tag[i+1:], k+"=") {
+				parts = append(parts, tag[start:i])
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	return append(parts, tag[start:])
+}
+
+// parseExtractTag parses a godet struct tag:
+//
+//	css=selector      the value is the text of the first element matching the CSS selector
+//	xpath=expression  the value is the text of the first node matching the XPath expression
+//	text=selector     the value is the text of the first element matching the selector (see QuerySelector)
+//	html=selector     the value is the inner HTML of the first element matching the selector
+//	attr=name         the value is the attribute of the element
+//	layout=layout     the layout used to parse time.Time values (the default is time.RFC3339)
+//
+// An empty selector selects the current element.
+func parseExtractTag(tag string) (*extractSpec, error) {
+	spec := &extractSpec{Mode: "text", layout: time.RFC3339}
+
+	for _, part := range splitExtractTag(tag) {
+		i := strings.Index(part, "=")
+		if i < 0 {
+			return nil, ExtractError("invalid tag: " + tag)
+		}
+
+		key, value := strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+
+		switch key {
+		case "css", "xpath":
+			spec.Engine, spec.Sel = key, value
+
+		case "text", "html":
+			spec.Mode = key
+			if value != "" {
+				spec.Engine, spec.Sel = parseSelector(value)
+			}
+
+		case "attr":
+			spec.Mode, spec.Attr = "attr", value
+
+		case "layout":
+			spec.layout = part[i+1:]
+
+		default:
+			return nil, ExtractError("invalid tag: " + tag)
+		}
+	}
+
+	return spec, nil
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isExtractList returns true if the type is extracted from a list of elements.
+func isExtractList(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+// isExtractStruct returns true if the type is extracted field by field.
+func isExtractStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// buildExtractSpec completes the spec for the specified type, adding the fields of structs.
+func buildExtractSpec(spec *extractSpec, t reflect.Type, depth int) error {
+	if depth > 32 {
+		return ExtractError("type is too deeply nested: " + t.String())
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if isExtractList(t) {
+		spec.All = true
+		t = t.Elem()
+
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+
+	if !isExtractStruct(t) {
+		return nil
+	}
+
+	spec.Fields = []*extractSpec{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag, ok := f.Tag.Lookup("godet")
+		if !ok || tag == "-" || f.PkgPath != "" {
+			continue
+		}
+
+		fspec, err := parseExtractTag(tag)
+		if err != nil {
+			return err
+		}
+
+		fspec.index = f.Index
+		fspec.name = f.Name
+
+		if err := buildExtractSpec(fspec, f.Type, depth+1); err != nil {
+			return err
+		}
+
+		spec.Fields = append(spec.Fields, fspec)
+	}
+
+	return nil
+}
+
+// extractJS extracts the values described by spec from the nodes matching the root selector.
+// Structs are returned as arrays of field values, lists as arrays of values.
+const extractJS = `(function(query, engine, value, spec) {
+	function normalize(s) {
+		return (s || "").replace(/\s+/g, " ").trim();
+	}
+
+	function extract(node, spec) {
+		if (spec.fields) {
+			return spec.fields.map(function(f) { return select(node, f); });
+		}
+
+		switch (spec.mode) {
+		case "html":
+			return node.nodeType === Node.ELEMENT_NODE ? node.innerHTML : node.textContent;
+		case "attr":
+			return node.nodeType === Node.ELEMENT_NODE ? node.getAttribute(spec.attr) : null;
+		default:
+			return normalize(node.nodeType === Node.ELEMENT_NODE && node.innerText !== undefined ? node.innerText : node.textContent);
+		}
+	}
+
+	function select(node, spec) {
+		var res = spec.sel ? query(node, spec.engine, spec.sel, spec.all, false) : (spec.all ? [node] : node);
+		if (spec.all) return res.map(function(n) { return extract(n, spec); });
+		return res ? extract(res, spec) : null;
+	}
+
+	spec.engine = engine;
+	spec.sel = value;
+	return select(document, spec);
+})`
+
+// numberRe matches the first number in a string (with optional thousands separators)
+var numberRe = regexp.MustCompile(`[-+]?(\d[\d,]*(\.\d*)?|\.\d+)([eE][-+]?\d+)?`)
+
+// extractValue converts the extracted data (a string, a list or nil) and stores it in v.
+func extractValue(v reflect.Value, data interface{}, spec *extractSpec, list bool) error {
+	if data == nil {
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return extractValue(v.Elem(), data, spec, list)
+	}
+
+	if list && spec.All {
+		items, ok := data.([]interface{})
+		if !ok {
+			return ExtractError(fmt.Sprintf("unexpected value for %v: %v", spec.name, data))
+		}
+
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := extractValue(slice.Index(i), item, spec, false); err != nil {
+				return err
+			}
+		}
+
+		v.Set(slice)
+		return nil
+	}
+
+	if spec.Fields != nil {
+		values, ok := data.([]interface{})
+		if !ok || len(values) != len(spec.Fields) {
+			return ExtractError(fmt.Sprintf("unexpected value for %v: %v", spec.name, data))
+		}
+
+		for i, f := range spec.Fields {
+			if err := extractValue(v.FieldByIndex(f.index), values[i], f, true); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	s, ok := data.(string)
+	if !ok {
+		return ExtractError(fmt.Sprintf("unexpected value for %v: %v", spec.name, data))
+	}
+
+	convertError := func() error {
+		return ExtractError(fmt.Sprintf("cannot convert %q to %v for %v", s, v.Type(), spec.name))
+	}
+
+	if v.Type() == timeType {
+		if s == "" {
+			return nil
+		}
+
+		t, err := time.Parse(spec.layout, s)
+		if err != nil {
+			return convertError()
+		}
+
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return ExtractError(fmt.Sprintf("unsupported type %v for %v", v.Type(), spec.name))
+		}
+
+		v.SetBytes([]byte(s))
+
+	case reflect.Bool:
+		// a present attribute or a non-empty text is true, unless it's a "false" value
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			b = true
+		}
+
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		n := strings.Replace(numberRe.FindString(s), ",", "", -1)
+		if n == "" {
+			if strings.TrimSpace(s) == "" {
+				return nil
+			}
+
+			return convertError()
+		}
+
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return convertError()
+		}
+
+		switch v.Kind() {
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(f)
+
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if f < 0 || v.OverflowUint(uint64(f)) {
+				return convertError()
+			}
+
+			v.SetUint(uint64(f))
+
+		default:
+			if v.OverflowInt(int64(f)) {
+				return convertError()
+			}
+
+			v.SetInt(int64(f))
+		}
+
+	default:
+		return ExtractError(fmt.Sprintf("unsupported type %v for %v", v.Type(), spec.name))
+	}
+
+	return nil
+}
+
+// Extract extracts data from the elements matching the selector (see QuerySelector for the selector syntax)
+// and stores it in the value pointed by dst, that can be a struct, a slice of structs or a basic type (or slice of).
+// All the values are extracted in the page in one round trip.
+//
+// If dst is a slice all the elements matching the selector are extracted, otherwise only the first one.
+// Struct fields are extracted according to the godet tag (fields without the tag are ignored):
+//
+//	css=selector      the text of the first element matching the CSS selector
+//	xpath=expression  the text of the first node matching the XPath expression
+//	text=selector     the text of the first element matching the selector (see QuerySelector)
+//	html=selector     the inner HTML of the first element matching the selector
+//	attr=name         the attribute of the element (can be combined with a selector)
+//	layout=layout     the layout used to parse time.Time values (the default is time.RFC3339)
+//
+// Selectors are relative to the element being extracted and an empty selector selects the element itself.
+// Nested structs are extracted from the element matching the field selector and slices from all the matching elements.
+//
+// Text is converted to the field type: numbers are parsed from the first number in the text (ignoring thousands
+// separators), booleans are true for present attributes and non-empty text that is not a false value and
+// types implementing encoding.TextUnmarshaler are unmarshaled from the text.
+// Fields for missing elements or attributes are left unchanged.
+//
+// Example:
+//
+//	type Product struct {
+//	    Name  string    `godet:"text=h2"`
+//	    Price float64   `godet:"css=.price,attr=data-value"`
+//	    URL   string    `godet:"css=a,attr=href"`
+//	    Tags  []string  `godet:"css=.tag"`
+//	    Added time.Time `godet:"css=.added,layout=Jan 2, 2006"`
+//	}
+//
+//	var products []Product
+//	err := debugger.Extract(".product", &products)
+func (remote *RemoteDebugger) Extract(selector string, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return ExtractError("destination must be a non-nil pointer")
+	}
+
+	spec := &extractSpec{Mode: "text", layout: time.RFC3339, name: v.Elem().Type().String()}
+	if err := buildExtractSpec(spec, v.Elem().Type(), 0); err != nil {
+		return err
+	}
+
+	engine, value := parseSelector(selector)
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	res, err := remote.evaluateObject(context.Background(), Params{
+		"expression":    fmt.Sprintf("%s(%s, %q, %s, %s)", extractJS, selectorEngineJS, engine, jsString(value), specJSON),
+		"returnByValue": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	data := res["value"]
+	if data == nil && !spec.All {
+		return ExtractError("no element matches " + selector)
+	}
+
+	return extractValue(v.Elem(), data, spec, true)
+}
@@ -0,0 +1,370 @@
+package godet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FormError is returned by FillForm if a field cannot be found or set.
+type FormError string
+
+// Error implements the error interface for FormError.
+func (err FormError) Error() string {
+	return "FormError:" + string(err)
+}
+
+// FillOption defines the functional options for FillForm
+type FillOption func(f *fillOptions)
+
+type fillOptions struct {
+	submit bool
+	wait   bool
+	ctx    context.Context
+	until  WaitCondition
+}
+
+// Submit submits the form after filling the fields.
+func Submit() FillOption {
+	return func(f *fillOptions) {
+		f.submit = true
+	}
+}
+
+// SubmitAndWait submits the form after filling the fields and waits for the resulting navigation
+// of the main frame to satisfy the wait condition (see NavigateAndWait).
+func SubmitAndWait(ctx context.Context, until WaitCondition) FillOption {
+	return func(f *fillOptions) {
+		f.submit = true
+		f.wait = true
+		f.ctx = ctx
+		f.until = until
+	}
+}
+
+// findFieldJS returns the form field for the specified key (name, id, label or aria-label).
+// For radio groups it returns the radio button matching the value (by value or label).
+const findFieldJS = `function(key, value) {
+	var root = this;
+
+	function normalize(s) {
+		return (s || "").replace(/\s+/g, " ").trim().toLowerCase();
+	}
+
+	function labelText(el) {
+		var labels = el.labels ? Array.prototype.slice.call(el.labels) : [];
+		return labels.map(function(l) { return normalize(l.textContent); });
+	}
+
+	var fields = Array.prototype.slice.call(root.querySelectorAll("[name]")).filter(function(el) { return el.name === key; });
+
+	if (!fields.length) {
+		var el = root.querySelector("#" + CSS.escape(key));
+		if (el) fields = [el];
+	}
+
+	if (!fields.length) {
+		var k = normalize(key);
+		fields = Array.prototype.slice.call(root.querySelectorAll("input, select, textarea, [contenteditable]")).filter(function(el) {
+			return labelText(el).indexOf(k) >= 0 || normalize(el.getAttribute("aria-label")) === k || normalize(el.placeholder) === k;
+		});
+	}
+
+	if (!fields.length) return null;
+
+	var field = fields[0];
+	if (field.type === "radio") {
+		var v = normalize(String(value));
+		var group = field.name ? Array.prototype.slice.call(root.querySelectorAll("input[type=radio]")).filter(function(el) { return el.name === field.name; }) : fields;
+		for (var i = 0; i < group.length; i++) {
+			if (normalize(group[i].value) === v || labelText(group[i]).indexOf(v) >= 0) return group[i];
+		}
+		return null;
+	}
+
+	return field;
+}`
+
+// fieldKindJS returns the kind of field: the input type, "select", "textarea" or "contenteditable".
+const fieldKindJS = `function() {
+	var tag = this.tagName.toLowerCase();
+	if (tag === "input") return (this.type || "text").toLowerCase();
+	if (tag === "select" || tag === "textarea") return tag;
+	return this.isContentEditable ? "contenteditable" : tag;
+}`
+
+// changeJS dispatches a change event, as when the user leaves a field after typing (the input events are fired by the typing).
+const changeJS = `function() {
+	this.dispatchEvent(new Event("change", {bubbles: true}));
+}`
+
+// setValueJS sets the value of the field, for input types that cannot be typed into (date, color, range, etc.)
+const setValueJS = `function(value) {
+	var proto = Object.getPrototypeOf(this);
+	var setter = Object.getOwnPropertyDescriptor(proto, "value").set;
+	setter.call(this, value);
+	this.dispatchEvent(new Event("input", {bubbles: true}));
+	this.dispatchEvent(new Event("change", {bubbles: true}));
+}`
+
+// selectContentJS selects the content of the field, so that the typed text replaces it,
+// and returns true if the field is not empty.
+const selectContentJS = `function() {
+	if (this.isContentEditable) {
+		var range = document.createRange();
+		range.selectNodeContents(this);
+		var sel = window.getSelection();
+		sel.removeAllRanges();
+		sel.addRange(range);
+		return this.textContent !== "";
+	}
+
+	if (typeof this.select === "function") this.select();
+	return this.value !== "";
+}`
+
+// selectJS selects the options matching the values (by value or label) and returns the number of selected options.
+const selectJS = `function(values) {
+	function normalize(s) {
+		return (s || "").replace(/\s+/g, " ").trim();
+	}
+
+	var selected = 0;
+	for (var i = 0; i < this.options.length; i++) {
+		var o = this.options[i];
+		var match = values.some(function(v) { return o.value === v || normalize(o.label) === normalize(v); });
+		if (!this.multiple && selected > 0) match = false;
+		o.selected = match;
+		if (match) selected++;
+	}
+
+	this.dispatchEvent(new Event("input", {bubbles: true}));
+	this.dispatchEvent(new Event("change", {bubbles: true}));
+	return selected;
+}`
+
+// checkJS sets the checked state of a checkbox or radio button, clicking it to fire the proper events.
+const checkJS = `function(checked) {
+	if (this.checked !== checked) this.click();
+	return this.checked === checked;
+}`
+
+// submitJS submits the form (or the form containing the element, or the first form inside the element).
+const submitJS = `function() {
+	var form = this.tagName === "FORM" ? this : (this.closest("form") || this.querySelector("form"));
+	if (!form) return false;
+	if (typeof form.requestSubmit === "function") form.requestSubmit(); else form.submit();
+	return true;
+}`
+
+// stringValues converts a field value to a list of strings.
+func stringValues(v interface{}) []string {
+	switch vv := v.(type) {
+	case []string:
+		return vv
+	case []interface{}:
+		values := make([]string, len(vv))
+		for i, s := range vv {
+			values[i] = fmt.Sprint(s)
+		}
+		return values
+	}
+
+	return []string{fmt.Sprint(v)}
+}
+
+// checkedValue returns the checked state for a checkbox value (a bool, or a string like "true", "on", "yes" or "1").
+func checkedValue(v interface{}) bool {
+	switch vv := v.(type) {
+	case bool:
+		return vv
+	case int:
+		return vv != 0
+	case float64:
+		return vv != 0
+	case string:
+		switch strings.ToLower(vv) {
+		case "true", "on", "yes", "1", "checked":
+			return true
+		}
+	}
+
+	return false
+}
+
+// FillForm fills the fields of the form matching the selector (see QuerySelector for the selector syntax).
+// The selector can also match an element containing the fields.
+//
+// Fields are located by name, id, label or aria-label (in this order). Values are set according to the field type:
+//
+//	text fields:     the value (converted to string) is typed in (see TypeText), replacing the current content
+//	select:          the options are selected by value or label ([]string for multiple selects)
+//	checkbox:        the value is a bool (or "true", "on", "yes", "1")
+//	radio:           the radio button in the group is selected by value or label
+//	file:            the file path (or []string of paths)
+//	date, color...:  the value is set directly
+//
+// Input and change events are dispatched as if the fields were filled by the user.
+// Use the Submit or SubmitAndWait options to submit the form.
+//
+// Example:
+//
+//	err := debugger.FillForm("form#signup", map[string]interface{}{
+//	    "email":    "user@example.com",
+//	    "Password": "secret",
+//	    "country":  "Italy",
+//	    "terms":    true,
+//	}, godet.SubmitAndWait(ctx, godet.UntilLoad))
+func (remote *RemoteDebugger) FillForm(selector string, values map[string]interface{}, options ...FillOption) error {
+	var opts fillOptions
+	for _, o := range options {
+		o(&opts)
+	}
+
+	form, err := remote.QueryElement(selector)
+	if err != nil {
+		return err
+	}
+
+	if form == nil {
+		return FormError("form not found: " + selector)
+	}
+
+	// fill the fields in a predictable order
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := remote.fillField(form, key, values[key]); err != nil {
+			return err
+		}
+	}
+
+	if !opts.submit {
+		return nil
+	}
+
+	submit := func() error {
+		v, err := form.callFunction(submitJS)
+		if err != nil {
+			return err
+		}
+
+		if submitted, _ := v.(bool); !submitted {
+			return FormError("no form to submit: " + selector)
+		}
+
+		return nil
+	}
+
+	if !opts.wait {
+		return submit()
+	}
+
+	frameID, err := remote.mainFrameID()
+	if err != nil {
+		return err
+	}
+
+	_, err = remote.waitNavigation(opts.ctx, frameID, opts.until, submit)
+	return err
+}
+
+// fillField locates a field in the form and sets its value.
+func (remote *RemoteDebugger) fillField(form *Element, key string, value interface{}) error {
+	objectID, err := form.ObjectID()
+	if err != nil {
+		return err
+	}
+
+	defer remote.ReleaseObject(objectID)
+
+	res, err := remote.callFunctionOn(objectID, findFieldJS, false, key, fmt.Sprint(value))
+	if err != nil {
+		return err
+	}
+
+	fieldID, _ := res["objectId"].(string)
+	if fieldID == "" {
+		return FormError("field not found: " + key)
+	}
+
+	defer remote.ReleaseObject(fieldID)
+
+	field, err := remote.ElementForObject(fieldID)
+	if err != nil {
+		return err
+	}
+
+	v, err := field.callFunction(fieldKindJS)
+	if err != nil {
+		return err
+	}
+
+	kind, _ := v.(string)
+
+	switch kind {
+	case "checkbox", "radio":
+		checked := true
+		if kind == "checkbox" {
+			checked = checkedValue(value)
+		}
+
+		v, err := field.callFunction(checkJS, checked)
+		if err != nil {
+			return err
+		}
+
+		if ok, _ := v.(bool); !ok {
+			return FormError(fmt.Sprintf("cannot set %v: %v", key, value))
+		}
+
+	case "select":
+		v, err := field.callFunction(selectJS, stringValues(value))
+		if err != nil {
+			return err
+		}
+
+		if n, _ := v.(float64); n == 0 {
+			return FormError(fmt.Sprintf("no option %v for %v", value, key))
+		}
+
+	case "file":
+		return field.SetFiles(stringValues(value)...)
+
+	case "date", "datetime-local", "month", "week", "time", "color", "range", "hidden":
+		_, err := field.callFunction(setValueJS, fmt.Sprint(value))
+		return err
+
+	default: // text fields, textarea, contenteditable
+		if err := field.Focus(); err != nil {
+			return err
+		}
+
+		v, err := field.callFunction(selectContentJS)
+		if err != nil {
+			return err
+		}
+
+		// the typed text replaces the selected content, or the content is deleted
+		if text := fmt.Sprint(value); text != "" {
+			err = remote.TypeText(text, 0)
+		} else if notEmpty, _ := v.(bool); notEmpty {
+			err = remote.KeyPress("Delete")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = field.callFunction(changeJS)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,431 @@
+package godet
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// isolatedWorld is the name of the isolated world created when a frame has no known execution context
+const isolatedWorld = "godet"
+
+// Frame represents a frame in the page frame tree.
+// The frame information is a snapshot: use FrameTree methods to get the updated information.
+type Frame struct {
+	ID             string `json:"id"`             // Frame unique identifier
+	ParentID       string `json:"parentId"`       // Parent frame identifier (empty for the main frame)
+	LoaderID       string `json:"loaderId"`       // Identifier of the loader associated with this frame
+	Name           string `json:"name"`           // Frame's name as specified in the tag
+	URL            string `json:"url"`            // Frame document's URL
+	SecurityOrigin string `json:"securityOrigin"` // Frame document's security origin
+	MimeType       string `json:"mimeType"`       // Frame document's mimeType as determined by the browser
+
+	tree *FrameTree
+}
+
+// FrameTree keeps track of the page frames (via Page.getFrameTree and the frameAttached/frameNavigated/frameDetached events)
+// and their execution contexts.
+//
+// Note that out-of-process iframes are not part of the page target and are not reported.
+//
+// Example:
+//
+//	tree, err := debugger.NewFrameTree()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer tree.Close()
+//
+//	for _, frame := range tree.Frames() {
+//	    title, _ := frame.Evaluate("document.title")
+//	    fmt.Println(frame.ID, frame.URL, title)
+//	}
+type FrameTree struct {
+	remote *RemoteDebugger
+
+	sync.Mutex
+	mainID   string
+	frames   map[string]*Frame
+	contexts map[string]int // default execution context, by frameId
+	isolated map[string]int // isolated world execution context, by frameId
+	remove   []func()
+}
+
+type frameTreeNode struct {
+	Frame       Frame           `json:"frame"`
+	ChildFrames []frameTreeNode `json:"childFrames"`
+}
+
+// NewFrameTree loads the current frame tree and keeps it updated.
+// Note that this enables Page and Runtime events.
+func (remote *RemoteDebugger) NewFrameTree() (*FrameTree, error) {
+	ft := &FrameTree{
+		remote:   remote,
+		frames:   map[string]*Frame{},
+		contexts: map[string]int{},
+		isolated: map[string]int{},
+	}
+
+	ft.remove = []func(){
+		remote.addEventHandler("Page.frameAttached", ft.onFrameAttached),
+		remote.addEventHandler("Page.frameNavigated", ft.onFrameNavigated),
+		remote.addEventHandler("Page.frameDetached", ft.onFrameDetached),
+		remote.addEventHandler("Runtime.executionContextCreated", ft.onContextCreated),
+		remote.addEventHandler("Runtime.executionContextDestroyed", ft.onContextDestroyed),
+		remote.addEventHandler("Runtime.executionContextsCleared", ft.onContextsCleared),
+	}
+
+	if err := remote.PageEvents(true); err != nil {
+		ft.Close()
+		return nil, err
+	}
+
+	if err := remote.RuntimeEvents(true); err != nil {
+		ft.Close()
+		return nil, err
+	}
+
+	rawReply, err := remote.sendRawReplyRequest("Page.getFrameTree", nil)
+	if err != nil {
+		ft.Close()
+		return nil, err
+	}
+
+	var res struct {
+		FrameTree frameTreeNode `json:"frameTree"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		ft.Close()
+		return nil, err
+	}
+
+	ft.Lock()
+	ft.mainID = res.FrameTree.Frame.ID
+	ft.addNode(res.FrameTree)
+	ft.Unlock()
+
+	return ft, nil
+}
+
+// addNode adds a frame and its children. It should be called with the lock held.
+func (ft *FrameTree) addNode(node frameTreeNode) {
+	frame := node.Frame
+	frame.tree = ft
+	ft.frames[frame.ID] = &frame
+
+	for _, child := range node.ChildFrames {
+		ft.addNode(child)
+	}
+}
+
+// Close stops tracking the frame tree.
+func (ft *FrameTree) Close() {
+	for _, remove := range ft.remove {
+		remove()
+	}
+}
+
+func (ft *FrameTree) onFrameAttached(params Params) {
+	id := params.String("frameId")
+
+	ft.Lock()
+	if ft.frames[id] == nil {
+		ft.frames[id] = &Frame{ID: id, ParentID: params.String("parentFrameId"), tree: ft}
+	}
+	ft.Unlock()
+}
+
+func (ft *FrameTree) onFrameNavigated(params Params) {
+	var frame Frame
+
+	b, _ := json.Marshal(params["frame"])
+	if err := json.Unmarshal(b, &frame); err != nil {
+		return
+	}
+
+	frame.tree = ft
+
+	ft.Lock()
+	ft.frames[frame.ID] = &frame
+	if frame.ParentID == "" {
+		ft.mainID = frame.ID
+	}
+	delete(ft.isolated, frame.ID)
+	ft.Unlock()
+}
+
+func (ft *FrameTree) onFrameDetached(params Params) {
+	ft.Lock()
+	ft.detach(params.String("frameId"))
+	ft.Unlock()
+}
+
+// detach removes a frame and its children. It should be called with the lock held.
+func (ft *FrameTree) detach(id string) {
+	for cid, f := range ft.frames {
+		if f.ParentID == id {
+			ft.detach(cid)
+		}
+	}
+
+	delete(ft.frames, id)
+	delete(ft.contexts, id)
+	delete(ft.isolated, id)
+}
+
+func (ft *FrameTree) onContextCreated(params Params) {
+	ec := params.Map("context")
+	aux, _ := ec["auxData"].(map[string]interface{})
+	if aux == nil {
+		return
+	}
+
+	frameID, _ := aux["frameId"].(string)
+	isDefault, _ := aux["isDefault"].(bool)
+	id, _ := ec["id"].(float64)
+
+	if frameID != "" && isDefault {
+		ft.Lock()
+		ft.contexts[frameID] = int(id)
+		ft.Unlock()
+	}
+}
+
+func (ft *FrameTree) onContextDestroyed(params Params) {
+	id := params.Int("executionContextId")
+
+	ft.Lock()
+	for frameID, cid := range ft.contexts {
+		if cid == id {
+			delete(ft.contexts, frameID)
+		}
+	}
+	for frameID, cid := range ft.isolated {
+		if cid == id {
+			delete(ft.isolated, frameID)
+		}
+	}
+	ft.Unlock()
+}
+
+func (ft *FrameTree) onContextsCleared(params Params) {
+	ft.Lock()
+	ft.contexts = map[string]int{}
+	ft.isolated = map[string]int{}
+	ft.Unlock()
+}
+
+// MainFrame returns the main frame.
+func (ft *FrameTree) MainFrame() *Frame {
+	return ft.Frame(ft.mainFrameID())
+}
+
+func (ft *FrameTree) mainFrameID() string {
+	ft.Lock()
+	defer ft.Unlock()
+
+	return ft.mainID
+}
+
+// Frame returns the frame with the specified id, or nil if the frame doesn't exist.
+func (ft *FrameTree) Frame(id string) *Frame {
+	ft.Lock()
+	defer ft.Unlock()
+
+	if f := ft.frames[id]; f != nil {
+		frame := *f
+		return &frame
+	}
+
+	return nil
+}
+
+// Frames returns all the frames in the page.
+func (ft *FrameTree) Frames() []*Frame {
+	ft.Lock()
+	defer ft.Unlock()
+
+	frames := make([]*Frame, 0, len(ft.frames))
+	for _, f := range ft.frames {
+		frame := *f
+		frames = append(frames, &frame)
+	}
+
+	return frames
+}
+
+// FrameByName returns the first frame with the specified name, or nil if there is no such frame.
+func (ft *FrameTree) FrameByName(name string) *Frame {
+	ft.Lock()
+	defer ft.Unlock()
+
+	for _, f := range ft.frames {
+		if f.Name == name {
+			frame := *f
+			return &frame
+		}
+	}
+
+	return nil
+}
+
+// Parent returns the parent frame, or nil for the main frame.
+func (f *Frame) Parent() *Frame {
+	if f.ParentID == "" {
+		return nil
+	}
+
+	return f.tree.Frame(f.ParentID)
+}
+
+// Children returns the child frames.
+func (f *Frame) Children() []*Frame {
+	var children []*Frame
+
+	for _, frame := range f.tree.Frames() {
+		if frame.ParentID == f.ID {
+			children = append(children, frame)
+		}
+	}
+
+	return children
+}
+
+// ExecutionContextID returns the id of the default execution context for the frame.
+// If the default context is not known, an isolated world is created in the frame.
+func (f *Frame) ExecutionContextID() (int, error) {
+	ft := f.tree
+
+	ft.Lock()
+	id, ok := ft.contexts[f.ID]
+	if !ok {
+		id, ok = ft.isolated[f.ID]
+	}
+	ft.Unlock()
+
+	if ok {
+		return id, nil
+	}
+
+	res, err := ft.remote.SendRequest("Page.createIsolatedWorld", Params{
+		"frameId":             f.ID,
+		"worldName":           isolatedWorld,
+		"grantUniveralAccess": true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	cid, ok := res["executionContextId"].(float64)
+	if !ok {
+		return 0, ErrorNoResponse
+	}
+
+	ft.Lock()
+	ft.isolated[f.ID] = int(cid)
+	ft.Unlock()
+
+	return int(cid), nil
+}
+
+// Evaluate executes a JavaScript expression in the context of the frame (see RemoteDebugger.Evaluate).
+func (f *Frame) Evaluate(expr string, options ...EvaluateOption) (interface{}, error) {
+	id, err := f.ExecutionContextID()
+	if err != nil {
+		return nil, err
+	}
+
+	return f.tree.remote.Evaluate(expr, append(options, ExecutionContext(id))...)
+}
+
+// Document returns the nodeId of the frame document.
+func (f *Frame) Document() (int, error) {
+	id, err := f.ExecutionContextID()
+	if err != nil {
+		return 0, err
+	}
+
+	remote := f.tree.remote
+
+	res, err := remote.evaluateObject(context.Background(), Params{
+		"expression": "document",
+		"contextId":  id,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	objectID, _ := res["objectId"].(string)
+	if objectID == "" {
+		return 0, ErrorNoResponse
+	}
+
+	defer remote.ReleaseObject(objectID)
+	return remote.RequestNodeForObject(objectID)
+}
+
+// QuerySelector returns the nodeId of the first element in the frame document matching the selector (or 0 if none).
+// See RemoteDebugger.QuerySelector for the selector syntax.
+func (f *Frame) QuerySelector(selector string) (int, error) {
+	docID, err := f.Document()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := f.tree.remote.QuerySelector(docID, selector)
+	if err != nil {
+		return 0, err
+	}
+
+	id, _ := res["nodeId"].(float64)
+	return int(id), nil
+}
+
+// QuerySelectorAll returns the nodeIds of all the elements in the frame document matching the selector.
+func (f *Frame) QuerySelectorAll(selector string) ([]int, error) {
+	docID, err := f.Document()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := f.tree.remote.QuerySelectorAll(docID, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, _ := res["nodeIds"].([]interface{})
+	nodeIDs := make([]int, 0, len(ids))
+	for _, id := range ids {
+		nodeIDs = append(nodeIDs, int(id.(float64)))
+	}
+
+	return nodeIDs, nil
+}
+
+// GetOuterHTML returns the HTML markup of the frame document.
+func (f *Frame) GetOuterHTML() (string, error) {
+	docID, err := f.Document()
+	if err != nil {
+		return "", err
+	}
+
+	return f.tree.remote.GetOuterHTML(docID)
+}
+
+// Navigate navigates the frame to the specified URL and waits for the navigation to complete (see NavigateAndWait).
+func (f *Frame) Navigate(ctx context.Context, url string, until WaitCondition) (string, int, error) {
+	return f.tree.remote.navigateAndWait(ctx, Params{"url": url, "frameId": f.ID}, until)
+}
+
+// WaitForNavigation waits for the next navigation of the frame to complete (see NavigateAndWait).
+// Navigations within the same document complete immediately.
+func (f *Frame) WaitForNavigation(ctx context.Context, until WaitCondition) (string, int, error) {
+	w, err := f.tree.remote.waitNavigation(ctx, f.ID, until, nil)
+	if err != nil {
+		return "", 0, err
+	}
+
+	url, status := w.result()
+	return url, status, nil
+}
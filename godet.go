@@ -28,6 +28,7 @@
 package godet
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -51,6 +52,13 @@ const (
 	// EventClosed represents the "RemoteDebugger.disconnected" event.
 	// It is emitted when we lose connection with the debugger and we stop reading events
 	EventDisconnect = "RemoteDebugger.disconnected"
+	// EventCrashed represents the "RemoteDebugger.crashed" event.
+	// It is emitted when crash detection is enabled and the renderer for the current tab crashes,
+	// is detached or stops responding (see EnableCrashDetection)
+	EventCrashed = "RemoteDebugger.crashed"
+	// EventRecovered represents the "RemoteDebugger.recovered" event.
+	// It is emitted after an attempt to recover from a crash (see EnableCrashDetection)
+	EventRecovered = "RemoteDebugger.recovered"
 
 	// NavigationProceed allows the navigation
 	NavigationProceed = NavigationResponse("Proceed")
@@ -87,11 +95,18 @@ const (
 	DefaultDownload = DownloadBehavior("default")
 )
 
+// IdType is the type of id passed to SetFileInputFiles
 type IdType int
 
 const (
+	// NodeId is a DOM node id
 	NodeId IdType = iota
+	// BackendNodeId is a backend node id
 	BackendNodeId
+	// ObjectId is a JavaScript object id.
+	//
+	// Deprecated: object ids are strings and cannot be passed as int, SetFileInputFiles returns ErrorObjectIdNotSupported.
+	// Use SetFileInputFilesForObject or Element.SetFiles instead.
 	ObjectId
 )
 
@@ -104,6 +119,28 @@ var (
 	ErrorNoResponse = errors.New("no response")
 	// ErrorClose is returned if a method is called after the connection has been close
 	ErrorClose = errors.New("closed")
+	// ErrorCrashed is returned to pending requests if the renderer for the current tab crashes
+	ErrorCrashed = errors.New("target crashed")
+	// ErrorNoHistoryEntry is returned by GoBack/GoForward if there is no entry to navigate to
+	ErrorNoHistoryEntry = errors.New("no history entry")
+	// ErrorNodeDetached is returned by Element methods if the element is not in the document anymore
+	ErrorNodeDetached = errors.New("node is detached from document")
+	// ErrorNotVisible is returned when an element has no visible area in the viewport
+	ErrorNotVisible = errors.New("element is not visible")
+	// ErrorElementNotFound is returned by the mouse actions if no element matches the selector
+	ErrorElementNotFound = errors.New("element not found")
+	// ErrorObjectIdNotSupported is returned by SetFileInputFiles for the ObjectId type
+	// (use SetFileInputFilesForObject instead)
+	ErrorObjectIdNotSupported = errors.New("object id not supported, use SetFileInputFilesForObject")
+	// ErrorDragNotStarted is returned by DragAndDrop if the browser didn't start the drag of a draggable source
+	ErrorDragNotStarted = errors.New("drag not started")
+	// ErrorTabNotClosed is returned by CloseTab with RunBeforeUnload if the tab is still open
+	// (i.e. the beforeunload dialog was dismissed)
+	ErrorTabNotClosed = errors.New("tab not closed")
+	// ErrorNotSerializable is returned by RemoteObject.JSONValue for DOM nodes
+	ErrorNotSerializable = errors.New("DOM nodes cannot be serialized")
+	// ErrorNotObject is returned by RemoteObject methods that require an object, for primitive values
+	ErrorNotObject = errors.New("not an object")
 
 	MaxReadBufferSize  = 0          // default gorilla/websocket buffer size
 	MaxWriteBufferSize = 100 * 1024 // this should be large enough to send large scripts
@@ -229,7 +266,10 @@ type EvaluateError struct {
 
 // Error implements the error interface for EvaluateError.
 func (err EvaluateError) Error() string {
-	desc := err.ErrorDetails["description"].(string)
+	desc, ok := err.ErrorDetails["description"].(string)
+	if !ok { // i.e. a thrown value that is not an Error object
+		desc, _ = err.ExceptionDetails["text"].(string)
+	}
 	if excp := err.ExceptionDetails; excp != nil {
 		if excp["exception"] != nil {
 			desc += fmt.Sprintf(" at line %v col %v",
@@ -314,11 +354,36 @@ type RemoteDebugger struct {
 	sync.Mutex           // Mutex for thread safety
 	closed     chan bool // Channel to signal connection closure
 
-	requests  chan Params                  // Channel for outgoing requests
-	responses map[int]chan json.RawMessage // Map of request IDs to response channels
-	callbacks map[string]EventCallback     // Map of event names to callback functions
-	domains   map[string]bool              // Map of enabled protocol domains
-	events    chan wsMessage               // Channel for incoming events
+	requests  chan Params                // Channel for outgoing requests
+	responses map[int]chan wsMessage     // Map of request IDs to response channels
+	callbacks map[string]EventCallback   // Map of event names to callback functions
+	handlers  map[string][]*eventHandler // Map of event names to internal event handlers
+	domains   map[string]bool            // Map of enabled protocol domains
+	events    chan wsMessage             // Channel for incoming events
+	crash     *crashMonitor              // Crash and hang detection (see EnableCrashDetection)
+	navPolicy func()                     // Removes the navigation policy handlers (see SetNavigationPolicy)
+
+	dialogPolicy func()   // Removes the dialog policy handler (see SetDialogPolicy)
+	dialogLog    []Dialog // Dialogs handled by the dialog policy
+
+	domGen int // DOM generation, incremented when node ids are invalidated (see Element)
+
+	eventSeq    int             // Number of events queued, to order events and replies (see sendRequestSeq)
+	dispatchSeq int             // Sequence number of the event being dispatched
+	docLock     sync.Mutex      // Serializes the document requests of the DOM mirrors
+	document    *mirrorDocument // Last document tree requested by a DOM mirror (see loadDocument)
+
+	styleSheets []StyleSheet // Stylesheets reported by CSS.styleSheetAdded (see StyleSheets)
+
+	keyModifiers KeyModifier // Modifier keys currently pressed (see KeyDown)
+
+	mouseX, mouseY int // Last mouse position (see MouseEvent)
+	mouseButtons   int // Mouse buttons currently pressed
+}
+
+// eventHandler wraps an internal event handler, so that it can be identified for removal.
+type eventHandler struct {
+	cb EventCallback
 }
 
 // Connect to the remote debugger and return `RemoteDebugger` object.
@@ -343,8 +408,9 @@ func Connect(port string, verbose bool, options ...ConnectOption) (*RemoteDebugg
 	remote := &RemoteDebugger{
 		http:      client,
 		requests:  make(chan Params),
-		responses: map[int]chan json.RawMessage{},
+		responses: map[int]chan wsMessage{},
 		callbacks: map[string]EventCallback{},
+		handlers:  map[string][]*eventHandler{},
 		domains:   map[string]bool{},
 		events:    make(chan wsMessage, 256),
 		closed:    make(chan bool),
@@ -360,6 +426,16 @@ func Connect(port string, verbose bool, options ...ConnectOption) (*RemoteDebugg
 		return nil, err
 	}
 
+	// node ids are invalidated when the document is updated
+	remote.addEventHandler("DOM.documentUpdated", func(params Params) {
+		remote.invalidateNodes()
+	})
+
+	// keep track of the stylesheets, when CSS events are enabled
+	remote.addEventHandler("CSS.styleSheetAdded", remote.onStyleSheetAdded)
+	remote.addEventHandler("CSS.styleSheetRemoved", remote.onStyleSheetRemoved)
+	remote.addEventHandler("Page.frameNavigated", remote.onFrameNavigated)
+
 	go remote.sendMessages()
 	go remote.processEvents()
 	return remote, nil
@@ -401,6 +477,8 @@ func (remote *RemoteDebugger) connectWs(tab *Tab) error {
 		remote.Lock()
 		ws := remote.ws
 		remote.ws, remote.current = nil, ""
+		remote.styleSheets = nil // stylesheets belong to the previous target
+		remote.document = nil
 		remote.Unlock()
 
 		_ = ws.Close()
@@ -475,6 +553,9 @@ type wsMessage struct {
 
 	Method string          `json:"Method"`
 	Params json.RawMessage `json:"Params"`
+
+	err error // set when the request was terminated without a reply
+	seq int   // number of events queued before this message was read
 }
 
 // SendRequest sends a request and returns the reply as a a map.
@@ -488,32 +569,69 @@ func (remote *RemoteDebugger) SendRequest(method string, params Params) (map[str
 
 // sendRawReplyRequest sends a request and returns the reply bytes.
 func (remote *RemoteDebugger) sendRawReplyRequest(method string, params Params) ([]byte, error) {
+	return remote.sendRequestContext(context.Background(), method, params)
+}
+
+// sendRequestContext sends a request and returns the reply bytes,
+// or the context error if the context is done before the reply is received.
+func (remote *RemoteDebugger) sendRequestContext(ctx context.Context, method string, params Params) ([]byte, error) {
+	reply, _, err := remote.sendRequestSeq(ctx, method, params)
+	return reply, err
+}
+
+// sendRequestSeq sends a request and returns the reply bytes and the sequence number of the last event
+// queued before the reply, so that the events older and newer than the reply can be told apart.
+func (remote *RemoteDebugger) sendRequestSeq(ctx context.Context, method string, params Params) ([]byte, int, error) {
 	remote.Lock()
 	if remote.ws == nil {
 		remote.Unlock()
-		return nil, ErrorClose
+		return nil, 0, ErrorClose
 	}
 
-	responseChan := make(chan json.RawMessage, 1)
+	responseChan := make(chan wsMessage, 1)
 	reqID := remote.reqID
 	remote.responses[reqID] = responseChan
 	remote.reqID++
 	remote.Unlock()
 
+	defer func() {
+		remote.Lock()
+		delete(remote.responses, reqID)
+		remote.Unlock()
+	}()
+
 	command := Params{
 		"id":     reqID,
 		"method": method,
 		"params": params,
 	}
 
-	remote.requests <- command
-	reply := <-responseChan
+	select {
+	case remote.requests <- command:
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
 
+	select {
+	case reply := <-responseChan:
+		return reply.Result, reply.seq, reply.err
+	case <-ctx.Done():
+		return nil, 0, ctx.Err()
+	}
+}
+
+// failPending terminates all the pending requests with the specified error.
+func (remote *RemoteDebugger) failPending(err error) {
 	remote.Lock()
-	delete(remote.responses, reqID)
-	remote.Unlock()
+	for id, ch := range remote.responses {
+		select {
+		case ch <- wsMessage{ID: id, err: err}:
+		default: // a reply is already queued
+		}
 
-	return reply, nil
+		delete(remote.responses, id)
+	}
+	remote.Unlock()
 }
 
 func (remote *RemoteDebugger) sendMessages() {
@@ -582,6 +700,11 @@ loop:
 
 				remote.Lock()
 				_, ok := remote.callbacks[message.Method]
+				ok = ok || len(remote.handlers[message.Method]) > 0
+				if ok {
+					remote.eventSeq++
+					message.seq = remote.eventSeq
+				}
 				remote.Unlock()
 
 				if !ok {
@@ -605,10 +728,14 @@ loop:
 
 				remote.Lock()
 				ch := remote.responses[message.ID]
+				message.seq = remote.eventSeq
 				remote.Unlock()
 
 				if ch != nil {
-					ch <- message.Result
+					select {
+					case ch <- message:
+					default: // the request was already terminated
+					}
 				}
 			}
 		}
@@ -617,9 +744,11 @@ loop:
 	// log.Println("exit readMessages", remoteClosed)
 
 	if remoteClosed {
+		remote.failPending(ErrorClose)
 		remote.events <- wsMessage{Method: EventClosed, Params: []byte("{}")}
 		close(remote.events)
 	} else if remote.socket() == ws { // we should still be connected but something is wrong
+		remote.failPending(ErrorClose)
 		remote.events <- wsMessage{Method: EventDisconnect, Params: []byte("{}")}
 	}
 }
@@ -627,20 +756,79 @@ loop:
 func (remote *RemoteDebugger) processEvents() {
 	for ev := range remote.events {
 		remote.Lock()
-		cb := remote.callbacks[ev.Method]
+		_, ok := remote.callbacks[ev.Method]
+		ok = ok || len(remote.handlers[ev.Method]) > 0
+		remote.dispatchSeq = ev.seq
 		remote.Unlock()
 
-		if cb != nil {
+		if ok {
 			var params Params
 			if err := json.Unmarshal(ev.Params, &params); err != nil {
 				log.Println("unmarshal", string(ev.Params), len(ev.Params), err)
 			} else {
-				cb(params)
+				remote.dispatchEvent(ev.Method, params)
 			}
 		}
 	}
 }
 
+// dispatchEvent calls the callback and the internal handlers registered for the specified event.
+func (remote *RemoteDebugger) dispatchEvent(method string, params Params) {
+	remote.Lock()
+	cb := remote.callbacks[method]
+	handlers := remote.handlers[method]
+	remote.Unlock()
+
+	if cb != nil {
+		cb(params)
+	}
+
+	for _, h := range handlers {
+		h.cb(params)
+	}
+}
+
+// addEventHandler registers an internal handler for the specified event.
+// Contrary to CallbackEvent, multiple handlers can be registered for the same event
+// and they don't replace the callback set by the user.
+//
+// Handlers are called from the event loop and should not send requests synchronously.
+// It returns a function that removes the handler.
+func (remote *RemoteDebugger) addEventHandler(method string, cb EventCallback) func() {
+	h := &eventHandler{cb: cb}
+
+	remote.Lock()
+	remote.handlers[method] = append(remote.handlers[method], h)
+	remote.Unlock()
+
+	return func() {
+		remote.Lock()
+		defer remote.Unlock()
+
+		handlers := remote.handlers[method]
+		for i, eh := range handlers {
+			if eh == h {
+				// copy, so that the slice can be safely traversed by dispatchEvent
+				handlers = append(handlers[:i:i], handlers[i+1:]...)
+				break
+			}
+		}
+
+		if len(handlers) == 0 {
+			delete(remote.handlers, method)
+		} else {
+			remote.handlers[method] = handlers
+		}
+	}
+}
+
+// handleAsync runs fn in a new goroutine.
+// All the asynchronous work that sends requests or calls user code (policies, callbacks, background probes)
+// goes through it, since replies cannot be waited for in the event loop and user code may take some time.
+func (remote *RemoteDebugger) handleAsync(fn func()) {
+	go fn()
+}
+
 // Version returns version information (protocol, browser, etc.).
 func (remote *RemoteDebugger) Version() (*Version, error) {
 	resp, err := responseError(remote.http.Get("/json/version", nil, nil))
@@ -713,22 +901,97 @@ func (remote *RemoteDebugger) ActivateTab(tab *Tab) error {
 		err = remote.connectWs(tab)
 
 		if err == nil {
-			for domain, state := range remote.domains {
-				remote.DomainEvents(domain, state)
-			}
+			remote.restoreDomains()
 		}
 	}
 
 	return err
 }
 
+// CloseOption defines the functional options for CloseTab
+type CloseOption func(c *closeOptions)
+
+type closeOptions struct {
+	beforeUnload bool
+	timeout      time.Duration
+}
+
+// RunBeforeUnload closes the current tab with Page.close, running the page beforeunload handlers,
+// and waits up to timeout for the tab to be closed.
+// A beforeunload dialog is handled by the dialog policy (see SetDialogPolicy): if the dialog is dismissed
+// the tab stays open and CloseTab returns ErrorTabNotClosed.
+//
+// Other tabs are not attached to this connection, so they are closed without running beforeunload handlers.
+func RunBeforeUnload(timeout time.Duration) CloseOption {
+	return func(c *closeOptions) {
+		c.beforeUnload = true
+		c.timeout = timeout
+	}
+}
+
 // CloseTab closes the specified tab.
-func (remote *RemoteDebugger) CloseTab(tab *Tab) error {
+// By default the tab is closed without running beforeunload handlers (see RunBeforeUnload).
+func (remote *RemoteDebugger) CloseTab(tab *Tab, options ...CloseOption) error {
+	var opts closeOptions
+	for _, o := range options {
+		o(&opts)
+	}
+
+	remote.Lock()
+	current := remote.current
+	remote.Unlock()
+
+	if opts.beforeUnload && tab.ID == current {
+		return remote.closeWithBeforeUnload(tab.ID, opts.timeout)
+	}
+
 	resp, err := responseError(remote.http.Get("/json/close/"+tab.ID, nil, nil))
 	resp.Close()
 	return err
 }
 
+// closeWithBeforeUnload closes the current tab with Page.close and waits for the tab to go away.
+func (remote *RemoteDebugger) closeWithBeforeUnload(tabID string, timeout time.Duration) error {
+	if err := remote.ClosePage(); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for {
+		tabs, err := remote.TabList("page")
+		if err != nil {
+			return err
+		}
+
+		closed := true
+		for _, t := range tabs {
+			if t.ID == tabID {
+				closed = false
+				break
+			}
+		}
+
+		if closed {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return ErrorTabNotClosed
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// ClosePage tries to close the current page, running its beforeunload handlers
+// (a beforeunload dialog is handled by the dialog policy, see SetDialogPolicy).
+// It doesn't wait for the page to be closed: use CloseTab with the RunBeforeUnload option for that.
+func (remote *RemoteDebugger) ClosePage() error {
+	_, err := remote.SendRequest("Page.close", nil)
+	return err
+}
+
 // NewTab creates a new tab.
 func (remote *RemoteDebugger) NewTab(url string) (*Tab, error) {
 	path := "/json/new"
@@ -864,7 +1127,62 @@ func (remote *RemoteDebugger) GetNavigationHistory() (int, []NavigationEntry, er
 	return int(history.Current), history.Entries, nil
 }
 
+// historyEntry returns the navigation entry at the specified offset from the current entry.
+func (remote *RemoteDebugger) historyEntry(offset int) (*NavigationEntry, error) {
+	current, entries, err := remote.GetNavigationHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	i := current + offset
+	if i < 0 || i >= len(entries) {
+		return nil, ErrorNoHistoryEntry
+	}
+
+	return &entries[i], nil
+}
+
+// GoToHistoryEntry navigates the current page to the history entry with the specified id (see GetNavigationHistory).
+// Use GoToHistoryEntryAndWait to wait for the navigation to complete.
+func (remote *RemoteDebugger) GoToHistoryEntry(entryID int64) error {
+	_, err := remote.SendRequest("Page.navigateToHistoryEntry", Params{
+		"entryId": entryID,
+	})
+
+	return err
+}
+
+// GoBack navigates to the previous history entry.
+// Returns ErrorNoHistoryEntry if the current entry is the first one.
+func (remote *RemoteDebugger) GoBack() error {
+	entry, err := remote.historyEntry(-1)
+	if err != nil {
+		return err
+	}
+
+	return remote.GoToHistoryEntry(entry.ID)
+}
+
+// GoForward navigates to the next history entry.
+// Returns ErrorNoHistoryEntry if the current entry is the last one.
+func (remote *RemoteDebugger) GoForward() error {
+	entry, err := remote.historyEntry(1)
+	if err != nil {
+		return err
+	}
+
+	return remote.GoToHistoryEntry(entry.ID)
+}
+
+// ResetNavigationHistory resets navigation history for the current page.
+func (remote *RemoteDebugger) ResetNavigationHistory() error {
+	_, err := remote.SendRequest("Page.resetNavigationHistory", nil)
+	return err
+}
+
 // SetControlNavigations toggles navigation throttling which allows programatic control over navigation and redirect response.
+//
+// Deprecated: Page.setControlNavigations is not supported anymore, use SetNavigationPolicy instead.
 func (remote *RemoteDebugger) SetControlNavigations(enabled bool) error {
 	_, err := remote.SendRequest("Page.setControlNavigations", Params{
 		"enabled": enabled,
@@ -874,6 +1192,8 @@ func (remote *RemoteDebugger) SetControlNavigations(enabled bool) error {
 }
 
 // ProcessNavigation should be sent in response to a navigationRequested or a redirectRequested event, telling the browser how to handle the navigation.
+//
+// Deprecated: Page.processNavigation is not supported anymore, use SetNavigationPolicy instead.
 func (remote *RemoteDebugger) ProcessNavigation(navigationID int, navigation NavigationResponse) error {
 	_, err := remote.SendRequest("Page.processNavigation", Params{
 		"response":     navigation,
@@ -1490,25 +1810,105 @@ func (remote *RemoteDebugger) FetchResponseBody(requestId string) ([]byte, error
 	}
 }
 
+// DocumentOption defines the functional options for GetDocument
+type DocumentOption func(p Params)
+
+// Depth sets the maximum depth at which children should be retrieved (-1 for the entire subtree, the default is 1)
+func Depth(depth int) DocumentOption {
+	return func(p Params) {
+		p["depth"] = depth
+	}
+}
+
+// Pierce specifies if iframes and shadow roots should be traversed when returning the subtree
+func Pierce(pierce bool) DocumentOption {
+	return func(p Params) {
+		p["pierce"] = pierce
+	}
+}
+
 // GetDocument gets the "Document" object as a DevTool node.
-func (remote *RemoteDebugger) GetDocument() (map[string]interface{}, error) {
-	return remote.SendRequest("DOM.getDocument", nil)
+// Note that this invalidates all the node ids previously returned.
+//
+// Example:
+//
+//	// the full document, including the content of shadow roots and iframes
+//	doc, err := debugger.GetDocument(godet.Depth(-1), godet.Pierce(true))
+func (remote *RemoteDebugger) GetDocument(options ...DocumentOption) (map[string]interface{}, error) {
+	params := Params{}
+	for _, o := range options {
+		o(params)
+	}
+
+	remote.invalidateNodes()
+	return remote.SendRequest("DOM.getDocument", params)
+}
+
+// invalidateNodes starts a new DOM generation, since the previous node ids are not valid anymore.
+func (remote *RemoteDebugger) invalidateNodes() {
+	remote.Lock()
+	remote.domGen++
+	remote.Unlock()
+}
+
+// domGeneration returns the current DOM generation.
+func (remote *RemoteDebugger) domGeneration() int {
+	remote.Lock()
+	defer remote.Unlock()
+
+	return remote.domGen
 }
 
 // QuerySelector gets the nodeId for a specified selector.
+//
+// The selector is a CSS selector, or an engine specific selector prefixed with the engine name:
+//
+//	css=div.item > a          CSS selector (same as without prefix)
+//	xpath=//a[@href]          XPath expression (the prefix can be omitted if the expression starts with //)
+//	text=Sign in              element containing the text (see SelectorText for exact and regular expression matches)
 func (remote *RemoteDebugger) QuerySelector(nodeID int, selector string) (map[string]interface{}, error) {
-	return remote.SendRequest("DOM.querySelector", Params{
-		"nodeId":   nodeID,
-		"selector": selector,
-	})
+	engine, value := parseSelector(selector)
+	if engine == SelectorCSS {
+		return remote.SendRequest("DOM.querySelector", Params{
+			"nodeId":   nodeID,
+			"selector": value,
+		})
+	}
+
+	ids, err := remote.querySelectorEngine(nodeID, engine, value, false)
+	if err != nil {
+		return nil, err
+	}
+
+	id := 0
+	if len(ids) > 0 {
+		id = ids[0]
+	}
+
+	return map[string]interface{}{"nodeId": float64(id)}, nil
 }
 
-// QuerySelectorAll gets a list of nodeId for the specified selectors.
+// QuerySelectorAll gets a list of nodeId for the specified selectors (see QuerySelector for the selector syntax).
 func (remote *RemoteDebugger) QuerySelectorAll(nodeID int, selector string) (map[string]interface{}, error) {
-	return remote.SendRequest("DOM.querySelectorAll", Params{
-		"nodeId":   nodeID,
-		"selector": selector,
-	})
+	engine, value := parseSelector(selector)
+	if engine == SelectorCSS {
+		return remote.SendRequest("DOM.querySelectorAll", Params{
+			"nodeId":   nodeID,
+			"selector": value,
+		})
+	}
+
+	ids, err := remote.querySelectorEngine(nodeID, engine, value, true)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeIDs := make([]interface{}, len(ids))
+	for i, id := range ids {
+		nodeIDs[i] = float64(id)
+	}
+
+	return map[string]interface{}{"nodeIds": nodeIDs}, nil
 }
 
 // ResolveNode returns some information about the node.
@@ -1527,6 +1927,29 @@ func (remote *RemoteDebugger) RequestNode(nodeID int) error {
 	return err
 }
 
+// RequestNodeForObject returns the nodeId for the node referenced by the JavaScript object id.
+func (remote *RemoteDebugger) RequestNodeForObject(objectID string) (int, error) {
+	for retry := 0; retry < 2; retry++ {
+		res, err := remote.SendRequest("DOM.requestNode", Params{
+			"objectId": objectID,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		if id, _ := res["nodeId"].(float64); id != 0 {
+			return int(id), nil
+		}
+
+		// the document needs to be requested before nodes can be pushed to the client
+		if _, err := remote.GetDocument(); err != nil {
+			return 0, err
+		}
+	}
+
+	return 0, ErrorNoResponse
+}
+
 // Focus sets focus on a specified node.
 func (remote *RemoteDebugger) Focus(nodeID int) error {
 	_, err := remote.SendRequest("DOM.focus", Params{
@@ -1543,6 +1966,9 @@ func (remote *RemoteDebugger) SetInputFiles(nodeID int, files []string) error {
 }
 
 // SetFileInputFiles sets files for the given file input element.
+//
+// Object ids are strings, so the ObjectId type cannot be used here and ErrorObjectIdNotSupported is returned:
+// use SetFileInputFilesForObject (or Element.SetFiles) instead.
 func (remote *RemoteDebugger) SetFileInputFiles(id int, files []string, idType IdType) error {
 	params := Params{"files": files}
 
@@ -1552,13 +1978,22 @@ func (remote *RemoteDebugger) SetFileInputFiles(id int, files []string, idType I
 	case BackendNodeId:
 		params["backendNodeId"] = id
 	case ObjectId:
-		params["objectId"] = id
+		return ErrorObjectIdNotSupported
 	}
 
 	_, err := remote.SendRequest("DOM.setFileInputFiles", params)
 	return err
 }
 
+// SetFileInputFilesForObject sets files for the file input element referenced by the JavaScript object id.
+func (remote *RemoteDebugger) SetFileInputFilesForObject(objectID string, files []string) error {
+	_, err := remote.SendRequest("DOM.setFileInputFiles", Params{
+		"files":    files,
+		"objectId": objectID,
+	})
+	return err
+}
+
 // SetAttributeValue sets the value for a specified attribute.
 func (remote *RemoteDebugger) SetAttributeValue(nodeID int, name, value string) error {
 	_, err := remote.SendRequest("DOM.setAttributeValue", Params{
@@ -1594,10 +2029,15 @@ func (remote *RemoteDebugger) SetOuterHTML(nodeID int, outerHTML string) error {
 }
 
 // GetBoxModel returns boxes for a DOM node identified by nodeId.
-func (remote *RemoteDebugger) GetBoxModel(nodeID int) (map[string]interface{}, error) {
-	return remote.SendRequest("DOM.getBoxModel", Params{
+func (remote *RemoteDebugger) GetBoxModel(nodeID int) (*BoxModel, error) {
+	rawReply, err := remote.sendRawReplyRequest("DOM.getBoxModel", Params{
 		"nodeId": nodeID,
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeBoxModel(rawReply)
 }
 
 // GetComputedStyleForNode returns the computed style for a DOM node identified by nodeId.
@@ -1682,33 +2122,10 @@ func (remote *RemoteDebugger) SetVirtualTimePolicy(policy VirtualTimePolicy, bud
 }
 
 // SendRune sends a character as keyboard input.
+// It calls TypeText, so characters are sent as key events for the US keyboard layout
+// (i.e. '\n' presses Enter) and the other characters are inserted with Input.insertText.
 func (remote *RemoteDebugger) SendRune(c rune) error {
-	if _, err := remote.SendRequest("Input.dispatchKeyEvent", Params{
-		"type":                  "rawKeyDown",
-		"windowsVirtualKeyCode": int(c),
-		"nativeVirtualKeyCode":  int(c),
-		"unmodifiedText":        string(c),
-		"text":                  string(c),
-	}); err != nil {
-		return err
-	}
-	if _, err := remote.SendRequest("Input.dispatchKeyEvent", Params{
-		"type":                  "char",
-		"windowsVirtualKeyCode": int(c),
-		"nativeVirtualKeyCode":  int(c),
-		"unmodifiedText":        string(c),
-		"text":                  string(c),
-	}); err != nil {
-		return err
-	}
-	_, err := remote.SendRequest("Input.dispatchKeyEvent", Params{
-		"type":                  "keyUp",
-		"windowsVirtualKeyCode": int(c),
-		"nativeVirtualKeyCode":  int(c),
-		"unmodifiedText":        string(c),
-		"text":                  string(c),
-	})
-	return err
+	return remote.TypeText(string(c), 0)
 }
 
 type MouseEvent string
@@ -1718,6 +2135,7 @@ const (
 	MouseMove    MouseEvent = "mouseMoved"
 	MousePress   MouseEvent = "mousePressed"
 	MouseRelease MouseEvent = "mouseReleased"
+	MouseWheel   MouseEvent = "mouseWheel"
 
 	NoModifier KeyModifier = 0
 	AltKey     KeyModifier = 1
@@ -1759,11 +2177,29 @@ func Clicks(c int) MouseOption {
 	}
 }
 
-// MouseEvent dispatches a mouse event to the page. An event can be MouseMove, MousePressed and MouseReleased.
+// WheelDelta sets the scroll amount for MouseWheel events.
+func WheelDelta(dx, dy float64) MouseOption {
+	return func(p Params) {
+		p["deltaX"] = dx
+		p["deltaY"] = dy
+	}
+}
+
+// mouseButtons maps the button names to the buttons bitmask
+var mouseButtons = map[string]int{
+	"left":   1,
+	"right":  2,
+	"middle": 4,
+}
+
+// MouseEvent dispatches a mouse event to the page. An event can be MouseMove, MousePressed, MouseReleased and MouseWheel.
 // An event always requires mouse coordinates, while other parameters are optional.
 //
 // To simulate mouse button presses, pass LeftButton()/RightButton()/MiddleButton() options and possibily key modifiers.
 // It is also possible to pass the number of clicks (2 for double clicks, etc.).
+// For MouseWheel events pass the WheelDelta option.
+//
+// The mouse position and the pressed buttons are tracked, so that events report the buttons currently pressed.
 func (remote *RemoteDebugger) MouseEvent(ev MouseEvent, x, y int, options ...MouseOption) error {
 	params := Params{
 		"type": ev,
@@ -1775,6 +2211,23 @@ func (remote *RemoteDebugger) MouseEvent(ev MouseEvent, x, y int, options ...Mou
 		o(params)
 	}
 
+	remote.Lock()
+	button := mouseButtons[params.String("button")]
+
+	switch ev {
+	case MousePress:
+		remote.mouseButtons |= button
+	case MouseRelease:
+		remote.mouseButtons &^= button
+	}
+
+	if _, ok := params["buttons"]; !ok {
+		params["buttons"] = remote.mouseButtons
+	}
+
+	remote.mouseX, remote.mouseY = x, y
+	remote.Unlock()
+
 	_, err := remote.SendRequest("Input.dispatchMouseEvent", params)
 	return err
 }
@@ -1811,12 +2264,27 @@ func GeneratePreview(enable bool) EvaluateOption {
 	}
 }
 
+// ObjectGroup sets the object group of the resulting remote objects (see EvaluateHandle and ReleaseObjectGroup).
+func ObjectGroup(group string) EvaluateOption {
+	return func(params Params) {
+		params["objectGroup"] = group
+	}
+}
+
 func ThrowOnSideEffect(enable bool) EvaluateOption {
 	return func(params Params) {
 		params["throwOnSideEffect"] = enable
 	}
 }
 
+// ExecutionContext specifies the execution context to evaluate the expression in
+// (i.e. a frame execution context, see Frame.ExecutionContextID).
+func ExecutionContext(id int) EvaluateOption {
+	return func(params Params) {
+		params["contextId"] = id
+	}
+}
+
 // Evaluate executes a JavaScript expression in the context of the current page.
 // The expression result is returned as an interface{}.
 // If the expression results in an error, an EvaluateError is returned.
@@ -1857,6 +2325,75 @@ func (remote *RemoteDebugger) Evaluate(expr string, options ...EvaluateOption) (
 	return result["value"], nil
 }
 
+// evaluateObject sends a Runtime.evaluate request and returns the resulting remote object.
+// If an exception was thrown an EvaluateError is returned.
+func (remote *RemoteDebugger) evaluateObject(ctx context.Context, params Params) (map[string]interface{}, error) {
+	rawReply, err := remote.sendRequestContext(ctx, "Runtime.evaluate", params)
+	if err != nil {
+		return nil, err
+	}
+
+	return remoteObject(rawReply)
+}
+
+// remoteObject decodes the reply of Runtime.evaluate or Runtime.callFunctionOn and returns the resulting remote object.
+// If an exception was thrown an EvaluateError is returned.
+func remoteObject(rawReply []byte) (map[string]interface{}, error) {
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		Result           map[string]interface{} `json:"result"`
+		ExceptionDetails map[string]interface{} `json:"exceptionDetails"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	if res.Result == nil {
+		return nil, ErrorNoResponse
+	}
+
+	if res.ExceptionDetails != nil {
+		return nil, EvaluateError{ErrorDetails: res.Result, ExceptionDetails: res.ExceptionDetails}
+	}
+
+	return res.Result, nil
+}
+
+// callFunctionOn calls the JavaScript function with the specified object as `this` and returns the resulting remote object.
+// The arguments are passed by value, except for *RemoteObject arguments (see callArgument).
+// If an exception was thrown an EvaluateError is returned.
+func (remote *RemoteDebugger) callFunctionOn(objectID, fn string, returnByValue bool, args ...interface{}) (map[string]interface{}, error) {
+	arguments := make([]Params, len(args))
+	for i, arg := range args {
+		arguments[i] = callArgument(arg)
+	}
+
+	rawReply, err := remote.sendRawReplyRequest("Runtime.callFunctionOn", Params{
+		"objectId":            objectID,
+		"functionDeclaration": fn,
+		"arguments":           arguments,
+		"returnByValue":       returnByValue,
+		"awaitPromise":        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return remoteObject(rawReply)
+}
+
+// ReleaseObject releases the remote object with the given id (as returned by Runtime methods).
+func (remote *RemoteDebugger) ReleaseObject(objectID string) error {
+	_, err := remote.SendRequest("Runtime.releaseObject", Params{
+		"objectId": objectID,
+	})
+	return err
+}
+
 // EvaluateWrap evaluates a list of expressions, EvaluateWrap wraps them in `(function(){ ... })()`.
 // Use a return statement to return a value.
 func (remote *RemoteDebugger) EvaluateWrap(expr string, options ...EvaluateOption) (interface{}, error) {
@@ -2025,6 +2562,7 @@ func (remote *RemoteDebugger) CloseBrowser() {
 func (remote *RemoteDebugger) DomainEvents(domain string, enable bool) error {
 	method := domain
 
+	remote.Lock()
 	if enable {
 		remote.domains[method] = true
 		method += ".enable"
@@ -2032,11 +2570,26 @@ func (remote *RemoteDebugger) DomainEvents(domain string, enable bool) error {
 		delete(remote.domains, method)
 		method += ".disable"
 	}
+	remote.Unlock()
 
 	_, err := remote.SendRequest(method, nil)
 	return err
 }
 
+// restoreDomains enables the events of the enabled domains again, after connecting to a new target.
+func (remote *RemoteDebugger) restoreDomains() {
+	remote.Lock()
+	domains := make([]string, 0, len(remote.domains))
+	for domain := range remote.domains {
+		domains = append(domains, domain)
+	}
+	remote.Unlock()
+
+	for _, domain := range domains {
+		remote.DomainEvents(domain, true)
+	}
+}
+
 // AllEvents enables event listening for all domains.
 func (remote *RemoteDebugger) AllEvents(enable bool) error {
 	domains, err := remote.GetDomains()
@@ -2058,6 +2611,12 @@ func (remote *RemoteDebugger) DOMEvents(enable bool) error {
 	return remote.DomainEvents("DOM", enable)
 }
 
+// CSSEvents enables CSS events (and the CSS methods that require the CSS agent).
+// Note that DOM events should be enabled first.
+func (remote *RemoteDebugger) CSSEvents(enable bool) error {
+	return remote.DomainEvents("CSS", enable)
+}
+
 // PageEvents enables Page events listening.
 func (remote *RemoteDebugger) PageEvents(enable bool) error {
 	return remote.DomainEvents("Page", enable)
@@ -2125,6 +2684,291 @@ func (remote *RemoteDebugger) AttachToTarget(targetId string) (string, error) {
 	return res["sessionId"].(string), nil
 }
 
+// BrowserContextOption defines the functional option for CreateBrowserContext
+type BrowserContextOption func(p Params)
+
+// ProxyServer sets the proxy server for the browser context (i.e. "socks5://192.168.1.1:1080")
+func ProxyServer(server string) BrowserContextOption {
+	return func(p Params) {
+		p["proxyServer"] = server
+	}
+}
+
+// ProxyBypassList sets the list of hosts (comma separated) that should bypass the context proxy
+func ProxyBypassList(list string) BrowserContextOption {
+	return func(p Params) {
+		p["proxyBypassList"] = list
+	}
+}
+
+// DisposeOnDetach instructs the browser to dispose the context when the debugging session disconnects
+func DisposeOnDetach(dispose bool) BrowserContextOption {
+	return func(p Params) {
+		p["disposeOnDetach"] = dispose
+	}
+}
+
+// CreateTargetOption defines the functional option for CreateTarget
+type CreateTargetOption func(p Params)
+
+// InBrowserContext creates the target in the specified browser context
+func InBrowserContext(contextID string) CreateTargetOption {
+	return func(p Params) {
+		p["browserContextId"] = contextID
+	}
+}
+
+// TargetSize sets the frame size of the new target (headless only)
+func TargetSize(width, height int) CreateTargetOption {
+	return func(p Params) {
+		p["width"] = width
+		p["height"] = height
+	}
+}
+
+// NewWindow creates the target in a new window instead of a new tab
+func NewWindow() CreateTargetOption {
+	return func(p Params) {
+		p["newWindow"] = true
+	}
+}
+
+// Background creates the target in background
+func Background() CreateTargetOption {
+	return func(p Params) {
+		p["background"] = true
+	}
+}
+
+// CreateBrowserContext creates a new empty browser context, similar to an incognito profile.
+// Cookies, storage and cache are not shared with the default context or other contexts.
+// Returns the browser context id.
+func (remote *RemoteDebugger) CreateBrowserContext(options ...BrowserContextOption) (string, error) {
+	params := Params{}
+
+	for _, o := range options {
+		o(params)
+	}
+
+	res, err := remote.SendRequest("Target.createBrowserContext", params)
+	if err != nil {
+		return "", err
+	}
+
+	if res == nil {
+		return "", ErrorNoResponse
+	}
+
+	return res["browserContextId"].(string), nil
+}
+
+// DisposeBrowserContext deletes a browser context. All the belonging pages will be closed without calling their beforeunload hooks.
+func (remote *RemoteDebugger) DisposeBrowserContext(contextID string) error {
+	_, err := remote.SendRequest("Target.disposeBrowserContext", Params{
+		"browserContextId": contextID,
+	})
+	return err
+}
+
+// GetBrowserContexts returns all browser contexts created with CreateBrowserContext.
+func (remote *RemoteDebugger) GetBrowserContexts() ([]string, error) {
+	rawReply, err := remote.sendRawReplyRequest("Target.getBrowserContexts", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var contexts struct {
+		IDs []string `json:"browserContextIds"`
+	}
+
+	if err := json.Unmarshal(rawReply, &contexts); err != nil {
+		return nil, err
+	}
+
+	return contexts.IDs, nil
+}
+
+// CreateTarget creates a new page (in the default or the specified browser context).
+// Returns the target id, that is also the tab id.
+func (remote *RemoteDebugger) CreateTarget(url string, options ...CreateTargetOption) (string, error) {
+	if url == "" {
+		url = "about:blank"
+	}
+
+	params := Params{"url": url}
+
+	for _, o := range options {
+		o(params)
+	}
+
+	res, err := remote.SendRequest("Target.createTarget", params)
+	if err != nil {
+		return "", err
+	}
+
+	if res == nil {
+		return "", ErrorNoResponse
+	}
+
+	return res["targetId"].(string), nil
+}
+
+// CloseTarget closes the target with given id.
+func (remote *RemoteDebugger) CloseTarget(targetID string) error {
+	_, err := remote.SendRequest("Target.closeTarget", Params{
+		"targetId": targetID,
+	})
+	return err
+}
+
+// BrowserContext represents an isolated browser context (see CreateBrowserContext)
+// and keeps track of the tabs opened in it.
+//
+// Example:
+//
+//	bctx, err := debugger.NewBrowserContext(godet.ProxyServer("localhost:3128"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer bctx.Dispose()
+//
+//	tab, err := bctx.NewTab("https://example.com")
+type BrowserContext struct {
+	ID string // browser context id
+
+	remote *RemoteDebugger
+	prev   string // tab we were connected to before opening tabs in this context
+	tabs   []*Tab
+}
+
+// NewBrowserContext creates a new browser context (see CreateBrowserContext) and returns a BrowserContext object.
+func (remote *RemoteDebugger) NewBrowserContext(options ...BrowserContextOption) (*BrowserContext, error) {
+	id, err := remote.CreateBrowserContext(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BrowserContext{ID: id, remote: remote}, nil
+}
+
+// NewTab creates a new tab in the browser context and connects the debugger to it.
+// As for ActivateTab, enabled domain events are re-enabled in the new tab.
+func (bctx *BrowserContext) NewTab(url string) (*Tab, error) {
+	remote := bctx.remote
+
+	id, err := remote.CreateTarget(url, InBrowserContext(bctx.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	remote.Lock()
+	current := remote.current
+	remote.Unlock()
+
+	if !bctx.hasTab(current) {
+		bctx.prev = current
+	}
+
+	tab := &Tab{ID: id, Type: "page", URL: url}
+	if err = remote.connectWs(tab); err != nil {
+		return nil, err
+	}
+
+	bctx.tabs = append(bctx.tabs, tab)
+
+	remote.restoreDomains()
+
+	return tab, nil
+}
+
+// Tabs returns the list of tabs opened in the browser context.
+func (bctx *BrowserContext) Tabs() []*Tab {
+	return bctx.tabs
+}
+
+func (bctx *BrowserContext) hasTab(id string) bool {
+	for _, t := range bctx.tabs {
+		if t.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Dispose closes all the tabs opened in the browser context and disposes the context.
+// If the debugger is connected to one of the context tabs, it is reconnected
+// to the tab that was active before the first call to NewTab (or to another tab, if that one was closed).
+// The tabs are closed and the context is disposed even if reconnecting fails, and the first error is returned.
+func (bctx *BrowserContext) Dispose() error {
+	remote := bctx.remote
+
+	remote.Lock()
+	current := remote.current
+	remote.Unlock()
+
+	var firstErr error
+
+	if bctx.hasTab(current) {
+		if err := bctx.reconnect(); err != nil {
+			firstErr = err
+		}
+	}
+
+	for _, t := range bctx.tabs {
+		if err := remote.CloseTab(t); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	bctx.tabs = nil
+
+	if err := remote.DisposeBrowserContext(bctx.ID); err != nil && firstErr == nil {
+		firstErr = err
+	}
+
+	return firstErr
+}
+
+// reconnect connects the debugger to the tab that was active before the first call to NewTab
+// or, if that tab is gone, to another tab not belonging to the browser context.
+func (bctx *BrowserContext) reconnect() error {
+	remote := bctx.remote
+
+	tabs, err := remote.TabList("page")
+	if err != nil {
+		return err
+	}
+
+	var tab *Tab
+
+	for _, t := range tabs {
+		if bctx.hasTab(t.ID) {
+			continue
+		}
+
+		if t.ID == bctx.prev {
+			tab = t
+			break
+		}
+
+		if tab == nil {
+			tab = t
+		}
+	}
+
+	if tab == nil {
+		return ErrorNoActiveTab
+	}
+
+	if err := remote.connectWs(tab); err != nil {
+		return err
+	}
+
+	remote.restoreDomains()
+	return nil
+}
+
 // RuntimeEvents enables Runtime events listening.
 func (remote *RemoteDebugger) RuntimeEvents(enable bool) error {
 	return remote.DomainEvents("Runtime", enable)
@@ -0,0 +1,160 @@
+package godet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+	"unicode/utf16"
+)
+
+// IMESetComposition sets the current IME composition text (starting a composition if needed).
+// The selection is the caret position (or the selected range) in the composition text, in UTF-16 code units.
+// An empty text cancels the composition.
+func (remote *RemoteDebugger) IMESetComposition(text string, selectionStart, selectionEnd int) error {
+	_, err := remote.SendRequest("Input.imeSetComposition", Params{
+		"text":           text,
+		"selectionStart": selectionStart,
+		"selectionEnd":   selectionEnd,
+	})
+	return err
+}
+
+// IMECommit commits the current composition with the specified text (ending the composition).
+// If there is no composition in progress the text is simply inserted.
+func (remote *RemoteDebugger) IMECommit(text string) error {
+	return remote.InsertText(text)
+}
+
+// IMECancel cancels the current composition.
+func (remote *RemoteDebugger) IMECancel() error {
+	return remote.IMESetComposition("", 0, 0)
+}
+
+// Compose simulates typing with an IME in the focused element: each step updates the composition
+// (i.e. the romaji or the candidate text) with the caret at the end, then the composition is committed with the final text,
+// waiting delay between steps.
+//
+// Example:
+//
+//	input.Focus()
+//	debugger.Compose([]string{"n", "に", "にh", "にほ", "にほn", "にほん"}, "日本", 0)
+func (remote *RemoteDebugger) Compose(steps []string, commit string, delay time.Duration) error {
+	for i, s := range steps {
+		if i > 0 && delay > 0 {
+			time.Sleep(delay)
+		}
+
+		caret := len(utf16.Encode([]rune(s)))
+		if err := remote.IMESetComposition(s, caret, caret); err != nil {
+			return err
+		}
+	}
+
+	if len(steps) > 0 && delay > 0 {
+		time.Sleep(delay)
+	}
+
+	return remote.IMECommit(commit)
+}
+
+// CompositionEvent is a composition event recorded in the page (see RecordCompositionEvents).
+type CompositionEvent struct {
+	Type   string `json:"type"`   // compositionstart, compositionupdate or compositionend
+	Data   string `json:"data"`   // composition text
+	Target string `json:"target"` // event target (i.e. "input#name")
+}
+
+// CompositionRecorder records the composition events fired in the page.
+type CompositionRecorder struct {
+	remote *RemoteDebugger
+	name   string
+}
+
+// compositionRecorderID is used to generate unique names for the recorders
+var compositionRecorderID int64
+
+// recordCompositionJS installs the composition event listeners (name is the global recorder variable)
+const recordCompositionJS = `(function(name) {
+	var events = [];
+
+	function target(el) {
+		if (!el || !el.tagName) return "";
+		return el.tagName.toLowerCase() + (el.id ? "#" + el.id : "");
+	}
+
+	function record(e) {
+		events.push({type: e.type, data: e.data || "", target: target(e.target)});
+	}
+
+	var types = ["compositionstart", "compositionupdate", "compositionend"];
+	types.forEach(function(t) { document.addEventListener(t, record, true); });
+
+	window[name] = {
+		events: events,
+		stop: function() {
+			types.forEach(function(t) { document.removeEventListener(t, record, true); });
+			delete window[name];
+		}
+	};
+})`
+
+// RecordCompositionEvents starts recording the compositionstart, compositionupdate and compositionend events
+// fired in the main frame document. Recording stops if the page navigates.
+//
+// Example:
+//
+//	rec, err := debugger.RecordCompositionEvents()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer rec.Stop()
+//
+//	debugger.Compose([]string{"k", "か"}, "か", 0)
+//
+//	events, _ := rec.Events()
+//	// compositionstart, compositionupdate "k", compositionupdate "か", compositionend "か"
+func (remote *RemoteDebugger) RecordCompositionEvents() (*CompositionRecorder, error) {
+	name := fmt.Sprintf("__godetComposition%d", atomic.AddInt64(&compositionRecorderID, 1))
+
+	if _, err := remote.evaluateObject(context.Background(), Params{
+		"expression": fmt.Sprintf("%s(%q)", recordCompositionJS, name),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &CompositionRecorder{remote: remote, name: name}, nil
+}
+
+// Events returns the composition events recorded so far.
+func (r *CompositionRecorder) Events() ([]CompositionEvent, error) {
+	res, err := r.remote.evaluateObject(context.Background(), Params{
+		"expression":    fmt.Sprintf("window[%q] ? window[%q].events : null", r.name, r.name),
+		"returnByValue": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if res["value"] == nil {
+		return nil, ErrorNoResponse // the recorder was stopped or the page navigated
+	}
+
+	b, err := json.Marshal(res["value"])
+	if err != nil {
+		return nil, err
+	}
+
+	var events []CompositionEvent
+	err = json.Unmarshal(b, &events)
+	return events, err
+}
+
+// Stop stops recording the composition events.
+func (r *CompositionRecorder) Stop() error {
+	_, err := r.remote.evaluateObject(context.Background(), Params{
+		"expression": fmt.Sprintf("window[%q] && window[%q].stop()", r.name, r.name),
+	})
+	return err
+}
@@ -0,0 +1,421 @@
+package godet
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyError is returned by the keyboard methods for unknown keys.
+type KeyError string
+
+// Error implements the error interface for KeyError.
+func (err KeyError) Error() string {
+	return "KeyError:" + string(err)
+}
+
+// keyDefinition describes a key in the US keyboard layout
+type keyDefinition struct {
+	key       string // key value (KeyboardEvent.key)
+	code      string // physical key (KeyboardEvent.code)
+	keyCode   int    // windows virtual key code
+	text      string // text generated by the key
+	shiftKey  string // key value with Shift
+	shiftText string // text generated by the key with Shift
+	location  int    // 0: standard, 1: left, 2: right, 3: numpad
+}
+
+// usKeyboard contains the key definitions for the US keyboard layout, by key value and code.
+var usKeyboard = map[string]*keyDefinition{}
+
+// keyAliases are alternative names for keys
+var keyAliases = map[string]string{
+	"Ctrl":    "Control",
+	"Option":  "Alt",
+	"Cmd":     "Meta",
+	"Command": "Meta",
+	"Esc":     "Escape",
+	"Return":  "Enter",
+	"Del":     "Delete",
+	"Up":      "ArrowUp",
+	"Down":    "ArrowDown",
+	"Left":    "ArrowLeft",
+	"Right":   "ArrowRight",
+	"Space":   " ",
+}
+
+func addKey(def *keyDefinition, names ...string) {
+	for _, name := range names {
+		if _, ok := usKeyboard[name]; !ok {
+			usKeyboard[name] = def
+		}
+	}
+}
+
+func init() {
+	// letters
+	for c := 'a'; c <= 'z'; c++ {
+		lower, upper := string(c), strings.ToUpper(string(c))
+		code, keyCode := "Key"+upper, int(c-'a')+65
+
+		addKey(&keyDefinition{key: lower, code: code, keyCode: keyCode, text: lower, shiftKey: upper, shiftText: upper}, code, lower)
+		addKey(&keyDefinition{key: upper, code: code, keyCode: keyCode, text: upper}, upper)
+	}
+
+	// digits
+	shifted := ")!@#$%^&*("
+	for i := 0; i < 10; i++ {
+		digit, symbol := string(rune('0'+i)), string(shifted[i])
+		code, keyCode := "Digit"+digit, 48+i
+
+		addKey(&keyDefinition{key: digit, code: code, keyCode: keyCode, text: digit, shiftKey: symbol, shiftText: symbol}, code, digit)
+		addKey(&keyDefinition{key: symbol, code: code, keyCode: keyCode, text: symbol}, symbol)
+	}
+
+	// punctuation
+	for _, p := range []struct {
+		code, key, shiftKey string
+		keyCode             int
+	}{
+		{"Semicolon", ";", ":", 186},
+		{"Equal", "=", "+", 187},
+		{"Comma", ",", "<", 188},
+		{"Minus", "-", "_", 189},
+		{"Period", ".", ">", 190},
+		{"Slash", "/", "?", 191},
+		{"Backquote", "`", "~", 192},
+		{"BracketLeft", "[", "{", 219},
+		{"Backslash", "\\", "|", 220},
+		{"BracketRight", "]", "}", 221},
+		{"Quote", "'", "\"", 222},
+	} {
+		addKey(&keyDefinition{key: p.key, code: p.code, keyCode: p.keyCode, text: p.key, shiftKey: p.shiftKey, shiftText: p.shiftKey}, p.code, p.key)
+		addKey(&keyDefinition{key: p.shiftKey, code: p.code, keyCode: p.keyCode, text: p.shiftKey}, p.shiftKey)
+	}
+
+	addKey(&keyDefinition{key: " ", code: "Space", keyCode: 32, text: " "}, " ")
+
+	// named keys
+	enter := &keyDefinition{key: "Enter", code: "Enter", keyCode: 13, text: "\r"}
+	addKey(enter, "Enter", "\r", "\n")
+
+	for _, k := range []struct {
+		key     string
+		keyCode int
+	}{
+		{"Backspace", 8},
+		{"Tab", 9},
+		{"Pause", 19},
+		{"CapsLock", 20},
+		{"Escape", 27},
+		{"PageUp", 33},
+		{"PageDown", 34},
+		{"End", 35},
+		{"Home", 36},
+		{"ArrowLeft", 37},
+		{"ArrowUp", 38},
+		{"ArrowRight", 39},
+		{"ArrowDown", 40},
+		{"PrintScreen", 44},
+		{"Insert", 45},
+		{"Delete", 46},
+		{"ContextMenu", 93},
+		{"NumLock", 144},
+		{"ScrollLock", 145},
+	} {
+		addKey(&keyDefinition{key: k.key, code: k.key, keyCode: k.keyCode}, k.key)
+	}
+
+	addKey(usKeyboard["Tab"], "\t")
+
+	for i := 1; i <= 12; i++ {
+		name := "F" + strconv.Itoa(i)
+		addKey(&keyDefinition{key: name, code: name, keyCode: 111 + i}, name)
+	}
+
+	// modifiers (the names without location are the left keys)
+	for _, m := range []struct {
+		key     string
+		keyCode int
+	}{
+		{"Shift", 16},
+		{"Control", 17},
+		{"Alt", 18},
+		{"Meta", 91},
+	} {
+		addKey(&keyDefinition{key: m.key, code: m.key + "Left", keyCode: m.keyCode, location: 1}, m.key+"Left", m.key)
+
+		rightCode := m.keyCode
+		if m.key == "Meta" {
+			rightCode = 92
+		}
+
+		addKey(&keyDefinition{key: m.key, code: m.key + "Right", keyCode: rightCode, location: 2}, m.key+"Right")
+	}
+
+	// numpad
+	for i := 0; i < 10; i++ {
+		digit := string(rune('0' + i))
+		addKey(&keyDefinition{key: digit, code: "Numpad" + digit, keyCode: 96 + i, text: digit, location: 3}, "Numpad"+digit)
+	}
+
+	for _, k := range []struct {
+		code, key string
+		keyCode   int
+	}{
+		{"NumpadMultiply", "*", 106},
+		{"NumpadAdd", "+", 107},
+		{"NumpadSubtract", "-", 109},
+		{"NumpadDecimal", ".", 110},
+		{"NumpadDivide", "/", 111},
+	} {
+		addKey(&keyDefinition{key: k.key, code: k.code, keyCode: k.keyCode, text: k.key, location: 3}, k.code)
+	}
+
+	addKey(&keyDefinition{key: "Enter", code: "NumpadEnter", keyCode: 13, text: "\r", location: 3}, "NumpadEnter")
+}
+
+// lookupKey returns the definition for the key (a key value like "a", "Enter" or "Shift", a code like "KeyA"
+// or an alias like "Ctrl" or "Esc").
+func lookupKey(key string) (*keyDefinition, error) {
+	if def, ok := usKeyboard[key]; ok {
+		return def, nil
+	}
+
+	if alias, ok := keyAliases[key]; ok {
+		return usKeyboard[alias], nil
+	}
+
+	return nil, KeyError("unknown key: " + key)
+}
+
+// editCommand is a key (by code) pressed with Control or Meta, with or without Shift
+type editCommand struct {
+	code  string
+	shift bool
+}
+
+// editCommands are the editing commands run by the browser for the keyboard shortcuts.
+// Synthesized key events don't trigger the platform shortcuts, so the commands are sent with the event.
+var editCommands = map[editCommand]string{
+	{"KeyA", false}: "selectAll",
+	{"KeyC", false}: "copy",
+	{"KeyV", false}: "paste",
+	{"KeyV", true}:  "pasteAndMatchStyle",
+	{"KeyX", false}: "cut",
+	{"KeyZ", false}: "undo",
+	{"KeyZ", true}:  "redo",
+	{"KeyY", false}: "redo",
+}
+
+// keyModifier returns the modifier for a modifier key (or NoModifier).
+func keyModifier(key string) KeyModifier {
+	switch key {
+	case "Alt":
+		return AltKey
+	case "Control":
+		return CtrlKey
+	case "Meta":
+		return MetaKey
+	case "Shift":
+		return ShiftKey
+	}
+
+	return NoModifier
+}
+
+// pressedModifiers returns the modifiers currently pressed with KeyDown.
+func (remote *RemoteDebugger) pressedModifiers() KeyModifier {
+	remote.Lock()
+	defer remote.Unlock()
+
+	return remote.keyModifiers
+}
+
+// setModifier updates the modifiers currently pressed.
+func (remote *RemoteDebugger) setModifier(m KeyModifier, pressed bool) {
+	remote.Lock()
+	defer remote.Unlock()
+
+	if pressed {
+		remote.keyModifiers |= m
+	} else {
+		remote.keyModifiers &^= m
+	}
+}
+
+// KeyDown dispatches a keydown event for the key (see KeyPress for the key names).
+// The modifiers are added to the modifier keys currently pressed (modifier keys stay pressed until KeyUp).
+//
+// If the key generates text (and Control, Alt or Meta are not pressed) the text is inserted, as for a physical keyboard.
+// The editing shortcuts pressed with Control or Meta (Ctrl+A, Ctrl+C, Ctrl+V, Ctrl+X, Ctrl+Z, Ctrl+Shift+Z, Ctrl+Y)
+// run the corresponding editing command (selectAll, copy, paste, cut, undo, redo).
+func (remote *RemoteDebugger) KeyDown(key string, modifiers KeyModifier) error {
+	def, err := lookupKey(key)
+	if err != nil {
+		return err
+	}
+
+	if m := keyModifier(def.key); m != NoModifier {
+		remote.setModifier(m, true)
+	}
+
+	modifiers |= remote.pressedModifiers()
+
+	key, text := def.key, def.text
+	if modifiers&ShiftKey != 0 && def.shiftKey != "" {
+		key, text = def.shiftKey, def.shiftText
+	}
+
+	if modifiers&(AltKey|CtrlKey|MetaKey) != 0 {
+		text = ""
+	}
+
+	params := Params{
+		"type":                  "rawKeyDown",
+		"modifiers":             modifiers,
+		"key":                   key,
+		"code":                  def.code,
+		"windowsVirtualKeyCode": def.keyCode,
+		"location":              def.location,
+		"isKeypad":              def.location == 3,
+	}
+
+	if text != "" {
+		params["type"] = "keyDown"
+		params["text"] = text
+		params["unmodifiedText"] = text
+	}
+
+	if modifiers&(CtrlKey|MetaKey) != 0 && modifiers&AltKey == 0 {
+		if cmd, ok := editCommands[editCommand{def.code, modifiers&ShiftKey != 0}]; ok {
+			params["commands"] = []string{cmd}
+		}
+	}
+
+	_, err = remote.SendRequest("Input.dispatchKeyEvent", params)
+	return err
+}
+
+// KeyUp dispatches a keyup event for the key (see KeyPress for the key names).
+func (remote *RemoteDebugger) KeyUp(key string, modifiers KeyModifier) error {
+	def, err := lookupKey(key)
+	if err != nil {
+		return err
+	}
+
+	if m := keyModifier(def.key); m != NoModifier {
+		remote.setModifier(m, false)
+	}
+
+	modifiers |= remote.pressedModifiers()
+
+	key = def.key
+	if modifiers&ShiftKey != 0 && def.shiftKey != "" {
+		key = def.shiftKey
+	}
+
+	_, err = remote.SendRequest("Input.dispatchKeyEvent", Params{
+		"type":                  "keyUp",
+		"modifiers":             modifiers,
+		"key":                   key,
+		"code":                  def.code,
+		"windowsVirtualKeyCode": def.keyCode,
+		"location":              def.location,
+	})
+	return err
+}
+
+// parseChord splits a key chord (i.e. "Ctrl+Shift+A") in the modifier keys and the main key.
+func parseChord(chord string) (modifiers []string, key string) {
+	if len(chord) <= 1 {
+		return nil, chord
+	}
+
+	parts := strings.Split(chord, "+")
+
+	// "Ctrl++" is Control and the "+" key
+	if strings.HasSuffix(chord, "++") {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	return parts[:len(parts)-1], parts[len(parts)-1]
+}
+
+// KeyPress presses and releases a key or a key chord.
+//
+// Keys are specified by key value (i.e. "a", "A", "Enter", "ArrowLeft", "F5", "Shift"), by code (i.e. "KeyA", "Digit1", "NumpadEnter")
+// or by alias ("Ctrl", "Cmd", "Esc", "Del", "Up"...). Chords are keys separated by "+", where all but the last are held
+// while the last one is pressed (i.e. "Ctrl+A", "Shift+Tab", "Ctrl+Shift+ArrowLeft").
+//
+// Example:
+//
+//	debugger.KeyPress("Ctrl+A")
+//	debugger.KeyPress("Backspace")
+//	debugger.TypeText("new text", 0)
+//	debugger.KeyPress("Enter")
+func (remote *RemoteDebugger) KeyPress(chord string) error {
+	modifiers, key := parseChord(chord)
+
+	for i, m := range modifiers {
+		if err := remote.KeyDown(m, NoModifier); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				remote.KeyUp(modifiers[j], NoModifier)
+			}
+
+			return err
+		}
+	}
+
+	err := remote.KeyDown(key, NoModifier)
+	if err == nil {
+		err = remote.KeyUp(key, NoModifier)
+	}
+
+	for i := len(modifiers) - 1; i >= 0; i-- {
+		if uerr := remote.KeyUp(modifiers[i], NoModifier); err == nil {
+			err = uerr
+		}
+	}
+
+	return err
+}
+
+// InsertText inserts the text, as for an IME or an emoji keyboard (no key events are dispatched).
+func (remote *RemoteDebugger) InsertText(text string) error {
+	_, err := remote.SendRequest("Input.insertText", Params{
+		"text": text,
+	})
+	return err
+}
+
+// TypeText types the text in the focused element, waiting delay between characters.
+// Characters in the US keyboard layout are typed with key events (newlines as Enter, tabs as Tab),
+// the other characters are inserted with Input.insertText.
+func (remote *RemoteDebugger) TypeText(text string, delay time.Duration) error {
+	for i, c := range text {
+		if i > 0 && delay > 0 {
+			time.Sleep(delay)
+		}
+
+		s := string(c)
+
+		if _, ok := usKeyboard[s]; !ok {
+			if err := remote.InsertText(s); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := remote.KeyDown(s, NoModifier); err != nil {
+			return err
+		}
+
+		if err := remote.KeyUp(s, NoModifier); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
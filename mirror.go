@@ -0,0 +1,1063 @@
+package godet
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MirrorNode is a snapshot of a node in the DOM mirror.
+type MirrorNode struct {
+	NodeID            int
+	ParentID          int // 0 for the document root
+	BackendNodeID     int
+	NodeType          int
+	NodeName          string
+	LocalName         string
+	NodeValue         string
+	Attributes        map[string]string
+	ChildNodeCount    int
+	ChildIDs          []int // the children of the node (not including shadow roots)
+	ShadowRootIDs     []int // the shadow roots of the node
+	ShadowRootType    string
+	ContentDocumentID int    // the content document for iframes
+	FrameID           string // the frame for iframe and document nodes
+	DocumentURL       string // the URL for document nodes
+}
+
+// MirrorChangeType defines the type of change notified by DOMMirror.Watch
+type MirrorChangeType string
+
+const (
+	// MirrorElementAdded is notified when a matching element is added to the document
+	MirrorElementAdded = MirrorChangeType("added")
+	// MirrorElementRemoved is notified when a matching element is removed from the document
+	MirrorElementRemoved = MirrorChangeType("removed")
+	// MirrorAttributeModified is notified when an attribute of a matching element is modified or removed
+	MirrorAttributeModified = MirrorChangeType("attribute")
+	// MirrorTextModified is notified when the text content of a matching element is modified
+	MirrorTextModified = MirrorChangeType("text")
+)
+
+// MirrorChange is a change notified by DOMMirror.Watch
+type MirrorChange struct {
+	Type MirrorChangeType
+	Node MirrorNode // the element (after the change)
+	Name string     // the attribute name, for MirrorAttributeModified
+}
+
+// DOMMirror maintains an in-memory copy of the DOM tree, synchronized with the DOM events.
+//
+// The tree is loaded with DOM.getDocument and reloaded when the node ids are invalidated
+// (when the document is updated or GetDocument is called). The mirrors of a debugger share the loaded trees,
+// so they don't invalidate each other, and the events received while loading are replayed on the new tree.
+//
+// Example:
+//
+//	mirror, err := debugger.NewDOMMirror()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer mirror.Close()
+//
+//	stop, err := mirror.Watch("li.item", func(c godet.MirrorChange) {
+//	    log.Println(c.Type, c.Node.NodeName, c.Node.Attributes["id"])
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer stop()
+type DOMMirror struct {
+	remote *RemoteDebugger
+
+	sync.Mutex
+	gen      int  // DOM generation of the current tree
+	loading  bool // the tree is being (re)loaded, events are queued
+	since    int  // sequence number of the last event applied before the queued events
+	pending  []mirrorEvent
+	root     *mirrorNode
+	nodes    map[int]*mirrorNode
+	watchers []*mirrorWatcher
+	remove   []func()
+}
+
+type mirrorNode struct {
+	info MirrorNode
+
+	parent          *mirrorNode
+	children        []*mirrorNode
+	shadowRoots     []*mirrorNode
+	contentDocument *mirrorNode
+}
+
+// mirrorEvent is an event received while the tree is loading, replayed if newer than the loaded tree
+type mirrorEvent struct {
+	seq    int
+	fn     func(params Params) []mirrorNotification
+	params Params
+}
+
+type mirrorWatcher struct {
+	selector selectorList
+	cb       func(MirrorChange)
+}
+
+// NewDOMMirror loads the document and keeps the in-memory tree updated.
+// Note that this enables DOM events.
+func (remote *RemoteDebugger) NewDOMMirror() (*DOMMirror, error) {
+	// events are queued until the document is loaded
+	m := &DOMMirror{remote: remote, nodes: map[int]*mirrorNode{}, loading: true}
+
+	m.remove = []func(){
+		remote.addEventHandler("DOM.documentUpdated", m.handle(nil)),
+		remote.addEventHandler("DOM.setChildNodes", m.handle(m.onSetChildNodes)),
+		remote.addEventHandler("DOM.childNodeInserted", m.handle(m.onChildNodeInserted)),
+		remote.addEventHandler("DOM.childNodeRemoved", m.handle(m.onChildNodeRemoved)),
+		remote.addEventHandler("DOM.childNodeCountUpdated", m.handle(m.onChildNodeCountUpdated)),
+		remote.addEventHandler("DOM.attributeModified", m.handle(m.onAttributeModified)),
+		remote.addEventHandler("DOM.attributeRemoved", m.handle(m.onAttributeRemoved)),
+		remote.addEventHandler("DOM.characterDataModified", m.handle(m.onCharacterDataModified)),
+		remote.addEventHandler("DOM.shadowRootPushed", m.handle(m.onShadowRootPushed)),
+		remote.addEventHandler("DOM.shadowRootPopped", m.handle(m.onShadowRootPopped)),
+	}
+
+	if err := remote.DOMEvents(true); err != nil {
+		m.Close()
+		return nil, err
+	}
+
+	remote.Lock()
+	m.since = remote.eventSeq
+	remote.Unlock()
+
+	if err := m.load(); err != nil {
+		m.Close()
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Close stops updating the tree.
+func (m *DOMMirror) Close() {
+	for _, remove := range m.remove {
+		remove()
+	}
+}
+
+// mirrorDocument is a full document tree requested by a mirror.
+type mirrorDocument struct {
+	gen  int                    // DOM generation of the node ids in the tree
+	seq  int                    // sequence number of the last event received before the tree (see sendRequestSeq)
+	root map[string]interface{} // root node
+}
+
+// loadDocument returns a full document tree, newer than the event with sequence number since.
+//
+// Requesting the document discards the node ids of the session, so a new DOM generation is started,
+// but the tree is shared by all the mirrors, that would otherwise invalidate each other's trees in turn.
+func (remote *RemoteDebugger) loadDocument(since int) (*mirrorDocument, error) {
+	remote.docLock.Lock()
+	defer remote.docLock.Unlock()
+
+	remote.Lock()
+	doc := remote.document
+	valid := doc != nil && doc.gen == remote.domGen && doc.seq >= since
+	remote.Unlock()
+
+	if valid {
+		return doc, nil
+	}
+
+	remote.invalidateNodes()
+	gen := remote.domGeneration()
+
+	rawReply, seq, err := remote.sendRequestSeq(context.Background(), "DOM.getDocument", Params{
+		"depth":  -1,
+		"pierce": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	res, err := unmarshal(rawReply)
+	if err != nil {
+		return nil, err
+	}
+
+	root, _ := res["root"].(map[string]interface{})
+	if root == nil {
+		return nil, ErrorNoResponse
+	}
+
+	doc = &mirrorDocument{gen: gen, seq: seq, root: root}
+
+	remote.Lock()
+	remote.document = doc
+	remote.Unlock()
+
+	return doc, nil
+}
+
+// load (re)loads the full document tree and replays the events received while loading that are newer than the tree.
+// Watchers are notified of the elements added and removed since the previous tree.
+func (m *DOMMirror) load() error {
+	for {
+		m.Lock()
+		since := m.since
+		m.Unlock()
+
+		doc, err := m.remote.loadDocument(since)
+		if err != nil {
+			m.Lock()
+			m.loading = false
+			m.pending = nil
+			m.Unlock()
+
+			return err
+		}
+
+		m.Lock()
+
+		if doc.gen != m.remote.domGeneration() {
+			// the node ids were invalidated again while loading
+			m.Unlock()
+			continue
+		}
+
+		old := m.root
+		m.nodes = map[int]*mirrorNode{}
+		m.root = m.add(doc.root, nil)
+		m.gen = doc.gen
+
+		changes := m.reloaded(old)
+
+		for _, ev := range m.pending {
+			if ev.seq > doc.seq && ev.fn != nil {
+				changes = append(changes, ev.fn(ev.params)...)
+			}
+		}
+
+		m.pending = nil
+		m.loading = false
+
+		m.Unlock()
+
+		notifyWatchers(changes)
+		return nil
+	}
+}
+
+// reloaded returns the notifications for the elements added and removed since the previous tree
+// (node ids changed, but backend node ids are stable). It should be called with the lock held.
+func (m *DOMMirror) reloaded(old *mirrorNode) (changes []mirrorNotification) {
+	if old == nil {
+		return
+	}
+
+	for _, w := range m.watchers {
+		before := map[int]*mirrorNode{}
+		old.walk(func(n *mirrorNode) {
+			if w.selector.match(n) {
+				before[n.info.BackendNodeID] = n
+			}
+		})
+
+		m.root.walk(func(n *mirrorNode) {
+			if w.selector.match(n) {
+				if before[n.info.BackendNodeID] == nil {
+					changes = append(changes, mirrorNotification{w, MirrorChange{Type: MirrorElementAdded, Node: n.snapshot()}})
+				}
+
+				delete(before, n.info.BackendNodeID)
+			}
+		})
+
+		for _, n := range before {
+			changes = append(changes, mirrorNotification{w, MirrorChange{Type: MirrorElementRemoved, Node: n.snapshot()}})
+		}
+	}
+
+	return
+}
+
+type mirrorNotification struct {
+	w *mirrorWatcher
+	c MirrorChange
+}
+
+func notifyWatchers(changes []mirrorNotification) {
+	for _, n := range changes {
+		n.w.cb(n.c)
+	}
+}
+
+// handle wraps an event handler, checking that the tree is still valid and notifying the watchers.
+// While the tree is loading the events are queued, and replayed by load if they are newer than the loaded tree.
+func (m *DOMMirror) handle(fn func(params Params) []mirrorNotification) EventCallback {
+	return func(params Params) {
+		m.remote.Lock()
+		gen, seq := m.remote.domGen, m.remote.dispatchSeq
+		m.remote.Unlock()
+
+		m.Lock()
+
+		if !m.loading && m.gen != gen {
+			// node ids have been invalidated: reload the tree, queueing this event and the following ones
+			m.loading = true
+			m.since = seq - 1
+			m.remote.handleAsync(func() { m.load() })
+		}
+
+		if m.loading {
+			m.pending = append(m.pending, mirrorEvent{seq: seq, fn: fn, params: params})
+			m.Unlock()
+			return
+		}
+
+		var changes []mirrorNotification
+		if fn != nil {
+			changes = fn(params)
+		}
+
+		m.Unlock()
+
+		notifyWatchers(changes)
+	}
+}
+
+// add adds a node (as returned by the DOM methods) and its descendants. It should be called with the lock held.
+func (m *DOMMirror) add(node map[string]interface{}, parent *mirrorNode) *mirrorNode {
+	p := Params(node)
+
+	n := &mirrorNode{parent: parent}
+	n.info = MirrorNode{
+		NodeID:         p.Int("nodeId"),
+		BackendNodeID:  p.Int("backendNodeId"),
+		NodeType:       p.Int("nodeType"),
+		NodeName:       p.String("nodeName"),
+		LocalName:      p.String("localName"),
+		NodeValue:      p.String("nodeValue"),
+		ChildNodeCount: p.Int("childNodeCount"),
+		ShadowRootType: p.String("shadowRootType"),
+		FrameID:        p.String("frameId"),
+		DocumentURL:    p.String("documentURL"),
+	}
+
+	if parent != nil {
+		n.info.ParentID = parent.info.NodeID
+	}
+
+	if attrs, _ := node["attributes"].([]interface{}); len(attrs) > 0 {
+		n.info.Attributes = map[string]string{}
+
+		for i := 0; i+1 < len(attrs); i += 2 {
+			name, _ := attrs[i].(string)
+			value, _ := attrs[i+1].(string)
+			n.info.Attributes[name] = value
+		}
+	}
+
+	m.nodes[n.info.NodeID] = n
+
+	if children, ok := node["children"].([]interface{}); ok {
+		n.children = m.addList(children, n)
+	}
+
+	if roots, ok := node["shadowRoots"].([]interface{}); ok {
+		n.shadowRoots = m.addList(roots, n)
+	}
+
+	if doc, ok := node["contentDocument"].(map[string]interface{}); ok {
+		n.contentDocument = m.add(doc, n)
+	}
+
+	return n
+}
+
+func (m *DOMMirror) addList(list []interface{}, parent *mirrorNode) (nodes []*mirrorNode) {
+	for _, c := range list {
+		if child, ok := c.(map[string]interface{}); ok {
+			nodes = append(nodes, m.add(child, parent))
+		}
+	}
+
+	return
+}
+
+// forget removes the node and its descendants from the node map. It should be called with the lock held.
+func (m *DOMMirror) forget(n *mirrorNode) {
+	n.walk(func(n *mirrorNode) {
+		delete(m.nodes, n.info.NodeID)
+	})
+}
+
+// matching returns the notifications for the elements in the subtree matching the watchers selectors.
+func (m *DOMMirror) matching(n *mirrorNode, t MirrorChangeType) (changes []mirrorNotification) {
+	for _, w := range m.watchers {
+		n.walk(func(n *mirrorNode) {
+			if w.selector.match(n) {
+				changes = append(changes, mirrorNotification{w, MirrorChange{Type: t, Node: n.snapshot()}})
+			}
+		})
+	}
+
+	return
+}
+
+func (m *DOMMirror) onSetChildNodes(params Params) (changes []mirrorNotification) {
+	parent := m.nodes[params.Int("parentId")]
+	if parent == nil {
+		return
+	}
+
+	for _, c := range parent.children {
+		m.forget(c)
+	}
+
+	list, _ := params["nodes"].([]interface{})
+	parent.children = m.addList(list, parent)
+	parent.info.ChildNodeCount = len(parent.children)
+	return
+}
+
+func (m *DOMMirror) onChildNodeInserted(params Params) []mirrorNotification {
+	parent := m.nodes[params.Int("parentNodeId")]
+	node := params.Map("node")
+	if parent == nil || node == nil {
+		return nil
+	}
+
+	n := m.add(node, parent)
+
+	pos := 0 // insert as first child, if there is no previous node
+	if prev := params.Int("previousNodeId"); prev != 0 {
+		for i, c := range parent.children {
+			if c.info.NodeID == prev {
+				pos = i + 1
+				break
+			}
+		}
+	}
+
+	parent.children = append(parent.children, nil)
+	copy(parent.children[pos+1:], parent.children[pos:])
+	parent.children[pos] = n
+	parent.info.ChildNodeCount = len(parent.children)
+
+	return m.matching(n, MirrorElementAdded)
+}
+
+func (m *DOMMirror) onChildNodeRemoved(params Params) []mirrorNotification {
+	parent := m.nodes[params.Int("parentNodeId")]
+	n := m.nodes[params.Int("nodeId")]
+	if parent == nil || n == nil {
+		return nil
+	}
+
+	// match before the node is detached, so that ancestors can be matched
+	changes := m.matching(n, MirrorElementRemoved)
+
+	for i, c := range parent.children {
+		if c == n {
+			parent.children = append(parent.children[:i], parent.children[i+1:]...)
+			break
+		}
+	}
+
+	parent.info.ChildNodeCount = len(parent.children)
+	m.forget(n)
+	return changes
+}
+
+func (m *DOMMirror) onChildNodeCountUpdated(params Params) []mirrorNotification {
+	if n := m.nodes[params.Int("nodeId")]; n != nil {
+		n.info.ChildNodeCount = params.Int("childNodeCount")
+	}
+
+	return nil
+}
+
+// setAttribute updates an attribute (or removes it if remove is true) and returns the notifications
+// for the watchers matching the element before or after the change.
+func (m *DOMMirror) setAttribute(n *mirrorNode, name, value string, remove bool) (changes []mirrorNotification) {
+	var before []bool
+	for _, w := range m.watchers {
+		before = append(before, w.selector.match(n))
+	}
+
+	if remove {
+		delete(n.info.Attributes, name)
+	} else {
+		if n.info.Attributes == nil {
+			n.info.Attributes = map[string]string{}
+		}
+
+		n.info.Attributes[name] = value
+	}
+
+	for i, w := range m.watchers {
+		if before[i] || w.selector.match(n) {
+			changes = append(changes, mirrorNotification{w, MirrorChange{Type: MirrorAttributeModified, Node: n.snapshot(), Name: name}})
+		}
+	}
+
+	return
+}
+
+func (m *DOMMirror) onAttributeModified(params Params) []mirrorNotification {
+	if n := m.nodes[params.Int("nodeId")]; n != nil {
+		return m.setAttribute(n, params.String("name"), params.String("value"), false)
+	}
+
+	return nil
+}
+
+func (m *DOMMirror) onAttributeRemoved(params Params) []mirrorNotification {
+	if n := m.nodes[params.Int("nodeId")]; n != nil {
+		return m.setAttribute(n, params.String("name"), "", true)
+	}
+
+	return nil
+}
+
+func (m *DOMMirror) onCharacterDataModified(params Params) (changes []mirrorNotification) {
+	n := m.nodes[params.Int("nodeId")]
+	if n == nil {
+		return
+	}
+
+	n.info.NodeValue = params.String("characterData")
+
+	if el := n.parent; el != nil {
+		for _, w := range m.watchers {
+			if w.selector.match(el) {
+				changes = append(changes, mirrorNotification{w, MirrorChange{Type: MirrorTextModified, Node: el.snapshot()}})
+			}
+		}
+	}
+
+	return
+}
+
+func (m *DOMMirror) onShadowRootPushed(params Params) []mirrorNotification {
+	host := m.nodes[params.Int("hostId")]
+	root := params.Map("root")
+	if host == nil || root == nil {
+		return nil
+	}
+
+	n := m.add(root, host)
+	host.shadowRoots = append(host.shadowRoots, n)
+	return m.matching(n, MirrorElementAdded)
+}
+
+func (m *DOMMirror) onShadowRootPopped(params Params) []mirrorNotification {
+	host := m.nodes[params.Int("hostId")]
+	n := m.nodes[params.Int("rootId")]
+	if host == nil || n == nil {
+		return nil
+	}
+
+	changes := m.matching(n, MirrorElementRemoved)
+
+	for i, r := range host.shadowRoots {
+		if r == n {
+			host.shadowRoots = append(host.shadowRoots[:i], host.shadowRoots[i+1:]...)
+			break
+		}
+	}
+
+	m.forget(n)
+	return changes
+}
+
+// walk calls fn for the node and all its descendants (including shadow roots and content documents).
+func (n *mirrorNode) walk(fn func(n *mirrorNode)) {
+	fn(n)
+
+	for _, r := range n.shadowRoots {
+		r.walk(fn)
+	}
+
+	for _, c := range n.children {
+		c.walk(fn)
+	}
+
+	if n.contentDocument != nil {
+		n.contentDocument.walk(fn)
+	}
+}
+
+// snapshot returns a copy of the node information.
+func (n *mirrorNode) snapshot() MirrorNode {
+	info := n.info
+
+	if n.info.Attributes != nil {
+		info.Attributes = make(map[string]string, len(n.info.Attributes))
+		for k, v := range n.info.Attributes {
+			info.Attributes[k] = v
+		}
+	}
+
+	for _, c := range n.children {
+		info.ChildIDs = append(info.ChildIDs, c.info.NodeID)
+	}
+
+	for _, r := range n.shadowRoots {
+		info.ShadowRootIDs = append(info.ShadowRootIDs, r.info.NodeID)
+	}
+
+	if n.contentDocument != nil {
+		info.ContentDocumentID = n.contentDocument.info.NodeID
+	}
+
+	return info
+}
+
+// Root returns the document node.
+func (m *DOMMirror) Root() MirrorNode {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.root == nil {
+		return MirrorNode{}
+	}
+
+	return m.root.snapshot()
+}
+
+// Node returns the node with the specified id, and false if the node is not in the tree.
+func (m *DOMMirror) Node(nodeID int) (MirrorNode, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	if n := m.nodes[nodeID]; n != nil {
+		return n.snapshot(), true
+	}
+
+	return MirrorNode{}, false
+}
+
+// NodeByBackendID returns the node with the specified backend node id, and false if the node is not in the tree.
+func (m *DOMMirror) NodeByBackendID(backendNodeID int) (MirrorNode, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, n := range m.nodes {
+		if n.info.BackendNodeID == backendNodeID {
+			return n.snapshot(), true
+		}
+	}
+
+	return MirrorNode{}, false
+}
+
+// Children returns the children of the node with the specified id.
+func (m *DOMMirror) Children(nodeID int) []MirrorNode {
+	m.Lock()
+	defer m.Unlock()
+
+	var children []MirrorNode
+
+	if n := m.nodes[nodeID]; n != nil {
+		for _, c := range n.children {
+			children = append(children, c.snapshot())
+		}
+	}
+
+	return children
+}
+
+// Len returns the number of nodes in the tree.
+func (m *DOMMirror) Len() int {
+	m.Lock()
+	defer m.Unlock()
+
+	return len(m.nodes)
+}
+
+// Match returns the elements in the tree matching the selector, in document order (see Watch for the supported selectors).
+// A SelectorError is returned if the selector is not supported.
+func (m *DOMMirror) Match(selector string) ([]MirrorNode, error) {
+	sel, err := parseSelectorList(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	var nodes []MirrorNode
+
+	if m.root != nil {
+		m.root.walk(func(n *mirrorNode) {
+			if sel.match(n) {
+				nodes = append(nodes, n.snapshot())
+			}
+		})
+	}
+
+	return nodes, nil
+}
+
+// Watch calls cb when elements matching the selector are added or removed, or their attributes or text change.
+// It returns a function that stops watching.
+//
+// The selector is matched in Go against the mirror tree and supports a subset of CSS: lists of selectors
+// made of type, universal, #id, .class and attribute selectors ([attr], [attr=value], [attr~=value],
+// [attr^=value], [attr$=value], [attr*=value]) with descendant and child combinators.
+// Other syntax (pseudo-classes, the + and ~ combinators, the xpath= and text= engines) returns a SelectorError.
+//
+// The callback is called from the event loop, or from the goroutine reloading the tree when the node ids
+// are invalidated, so it should not send requests synchronously nor block.
+func (m *DOMMirror) Watch(selector string, cb func(MirrorChange)) (func(), error) {
+	sel, err := parseSelectorList(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &mirrorWatcher{selector: sel, cb: cb}
+
+	m.Lock()
+	m.watchers = append(m.watchers, w)
+	m.Unlock()
+
+	return func() {
+		m.Lock()
+		defer m.Unlock()
+
+		for i, mw := range m.watchers {
+			if mw == w {
+				// copy, so that the list can be safely traversed by the handlers
+				m.watchers = append(m.watchers[:i:i], m.watchers[i+1:]...)
+				break
+			}
+		}
+	}, nil
+}
+
+// A minimal CSS selector matcher, for the mirror tree.
+
+// SelectorError is returned by DOMMirror.Match and DOMMirror.Watch for unsupported selectors.
+type SelectorError string
+
+// Error implements the error interface for SelectorError.
+func (err SelectorError) Error() string {
+	return "SelectorError:" + string(err)
+}
+
+type attrSelector struct {
+	name, op, value string
+}
+
+type compoundSelector struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+	child   bool // combinator with the previous compound: child (>) or descendant
+}
+
+type complexSelector []compoundSelector
+
+type selectorList []complexSelector
+
+// parseSelectorList parses a list of selectors, returning a SelectorError for unsupported syntax.
+func parseSelectorList(selector string) (list selectorList, err error) {
+	engine, value := parseSelector(selector)
+	if engine != SelectorCSS {
+		return nil, SelectorError("unsupported selector engine " + engine + ": " + selector)
+	}
+
+	for _, s := range splitOutside(value, ',') {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, SelectorError("empty selector: " + selector)
+		}
+
+		sel, err := parseComplexSelector(s)
+		if err != nil {
+			return nil, SelectorError(err.Error() + " in " + selector)
+		}
+
+		list = append(list, sel)
+	}
+
+	return
+}
+
+// splitOutside splits s on sep, ignoring separators inside brackets or quotes.
+func splitOutside(s string, sep byte) (parts []string) {
+	depth, quote, start := 0, byte(0), 0
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+func parseComplexSelector(s string) (sel complexSelector, err error) {
+	child := false
+
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t\n")
+		if s == "" {
+			break
+		}
+
+		if s[0] == '>' {
+			if child || len(sel) == 0 {
+				return nil, errors.New("misplaced combinator at " + strconv.Quote(s))
+			}
+
+			child = true
+			s = s[1:]
+			continue
+		}
+
+		// find the end of the compound selector
+		end, depth, quote := len(s), 0, byte(0)
+	scan:
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+
+			switch {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case c == '"' || c == '\'':
+				quote = c
+			case c == '[':
+				depth++
+			case c == ']':
+				depth--
+			case depth == 0 && (c == ' ' || c == '\t' || c == '\n' || c == '>'):
+				end = i
+				break scan
+			}
+		}
+
+		cs, err := parseCompoundSelector(s[:end])
+		if err != nil {
+			return nil, err
+		}
+
+		cs.child = child
+		sel = append(sel, cs)
+
+		child = false
+		s = s[end:]
+	}
+
+	if child {
+		return nil, errors.New("missing selector after combinator")
+	}
+
+	return
+}
+
+// parseCompoundSelector parses a compound selector (i.e. div#main.content[title]).
+func parseCompoundSelector(s string) (cs compoundSelector, err error) {
+	unsupported := func() error {
+		return errors.New("unsupported syntax at " + strconv.Quote(s))
+	}
+
+	ident := func() string {
+		i := 0
+		for i < len(s) && (s[i] == '-' || s[i] == '_' || s[i] >= 0x80 ||
+			(s[i] >= 'a' && s[i] <= 'z') || (s[i] >= 'A' && s[i] <= 'Z') || (s[i] >= '0' && s[i] <= '9')) {
+			i++
+		}
+
+		id := s[:i]
+		s = s[i:]
+		return id
+	}
+
+	if strings.HasPrefix(s, "*") {
+		s = s[1:]
+	} else {
+		cs.tag = strings.ToLower(ident())
+	}
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '#':
+			s = s[1:]
+			if cs.id = ident(); cs.id == "" {
+				return cs, unsupported()
+			}
+
+		case '.':
+			s = s[1:]
+			class := ident()
+			if class == "" {
+				return cs, unsupported()
+			}
+
+			cs.classes = append(cs.classes, class)
+
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return cs, errors.New("unterminated attribute selector at " + strconv.Quote(s))
+			}
+
+			attr := s[1:end]
+			s = s[end+1:]
+
+			as := attrSelector{name: strings.TrimSpace(attr)}
+
+			if i := strings.IndexByte(attr, '='); i > 0 {
+				as.name, as.op, as.value = attr[:i], "=", strings.TrimSpace(attr[i+1:])
+
+				if strings.ContainsRune("~^$*|", rune(as.name[len(as.name)-1])) {
+					as.op = as.name[len(as.name)-1:] + "="
+					as.name = as.name[:len(as.name)-1]
+				}
+
+				as.name = strings.TrimSpace(as.name)
+				as.value = strings.Trim(as.value, `"'`)
+			}
+
+			if as.name == "" {
+				return cs, errors.New("invalid attribute selector [" + attr + "]")
+			}
+
+			cs.attrs = append(cs.attrs, as)
+
+		default: // pseudo-classes, + and ~ combinators, etc.
+			return cs, unsupported()
+		}
+	}
+
+	return
+}
+
+func (list selectorList) match(n *mirrorNode) bool {
+	for _, sel := range list {
+		if sel.matchAt(n, len(sel)-1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchAt matches the compound selectors up to i, right to left, starting from the node.
+func (sel complexSelector) matchAt(n *mirrorNode, i int) bool {
+	if i < 0 || !sel[i].match(n) {
+		return false
+	}
+
+	if i == 0 {
+		return true
+	}
+
+	for p := n.parentElement(); p != nil; p = p.parentElement() {
+		if sel.matchAt(p, i-1) {
+			return true
+		}
+
+		if sel[i].child {
+			break
+		}
+	}
+
+	return false
+}
+
+// parentElement returns the parent node, if it's an element (selectors don't cross shadow roots or documents).
+func (n *mirrorNode) parentElement() *mirrorNode {
+	if p := n.parent; p != nil && p.info.NodeType == 1 && n.info.NodeType == 1 {
+		return p
+	}
+
+	return nil
+}
+
+func (cs compoundSelector) match(n *mirrorNode) bool {
+	if n.info.NodeType != 1 { // ELEMENT_NODE
+		return false
+	}
+
+	if cs.tag != "" && cs.tag != strings.ToLower(n.info.LocalName) && cs.tag != strings.ToLower(n.info.NodeName) {
+		return false
+	}
+
+	attrs := n.info.Attributes
+
+	if cs.id != "" && attrs["id"] != cs.id {
+		return false
+	}
+
+	for _, class := range cs.classes {
+		if !containsWord(attrs["class"], class) {
+			return false
+		}
+	}
+
+	for _, as := range cs.attrs {
+		value, ok := attrs[as.name]
+		if !ok {
+			return false
+		}
+
+		switch as.op {
+		case "=":
+			ok = value == as.value
+		case "~=":
+			ok = containsWord(value, as.value)
+		case "^=":
+			ok = as.value != "" && strings.HasPrefix(value, as.value)
+		case "$=":
+			ok = as.value != "" && strings.HasSuffix(value, as.value)
+		case "*=":
+			ok = as.value != "" && strings.Contains(value, as.value)
+		case "|=":
+			ok = value == as.value || strings.HasPrefix(value, as.value+"-")
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// containsWord returns true if the whitespace separated list contains the word.
+func containsWord(list, word string) bool {
+	for _, w := range strings.Fields(list) {
+		if w == word {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,276 @@
+package godet
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// dragSteps is the number of mouse moves between the source and the target in DragAndDrop
+const dragSteps = 10
+
+// dragInterceptTimeout is how long DragAndDrop waits for the browser to report an HTML5 drag (see DragTimeout)
+const dragInterceptTimeout = 2 * time.Second
+
+// draggableJS returns true if the element or one of its ancestors is draggable (HTML5 drag and drop)
+const draggableJS = `function() {
+	for (var el = this; el; el = el.parentElement) {
+		if (el.draggable) return true;
+	}
+	return false;
+}`
+
+// DragOption defines the functional options for DragAndDrop
+type DragOption func(d *dragOptions)
+
+type dragOptions struct {
+	timeout time.Duration
+}
+
+// DragTimeout sets how long DragAndDrop waits for the browser to start the HTML5 drag of a draggable source
+// (the default is 2 seconds).
+func DragTimeout(timeout time.Duration) DragOption {
+	return func(d *dragOptions) {
+		d.timeout = timeout
+	}
+}
+
+// TargetError is returned by the mouse and touch actions for an unsupported target.
+type TargetError string
+
+// Error implements the error interface for TargetError.
+func (err TargetError) Error() string {
+	return "TargetError:" + string(err)
+}
+
+// targetElement returns the element for a mouse action target, that can be a selector (see QuerySelector) or an *Element.
+func (remote *RemoteDebugger) targetElement(target interface{}) (*Element, error) {
+	switch t := target.(type) {
+	case *Element:
+		if t == nil {
+			return nil, TargetError("nil *Element")
+		}
+
+		return t, nil
+
+	case string:
+		elem, err := remote.QueryElement(t)
+		if err != nil {
+			return nil, err
+		}
+
+		if elem == nil {
+			return nil, ErrorElementNotFound
+		}
+
+		return elem, nil
+	}
+
+	return nil, TargetError(fmt.Sprintf("unsupported target type %T (use a selector or an *Element)", target))
+}
+
+// targetPoint scrolls the target into view and returns its clickable point (see Element.ClickablePoint).
+func (remote *RemoteDebugger) targetPoint(target interface{}) (int, int, error) {
+	elem, err := remote.targetElement(target)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := elem.ScrollIntoView(); err != nil {
+		return 0, 0, err
+	}
+
+	p, err := elem.ClickablePoint()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int(math.Round(p.X)), int(math.Round(p.Y)), nil
+}
+
+// clickAt moves the mouse to the point and clicks count times (with the left button, unless specified in the options).
+func (remote *RemoteDebugger) clickAt(x, y, count int, options []MouseOption) error {
+	if err := remote.MouseEvent(MouseMove, x, y); err != nil {
+		return err
+	}
+
+	for c := 1; c <= count; c++ {
+		opts := append([]MouseOption{LeftButton(), Clicks(c)}, options...)
+
+		if err := remote.MouseEvent(MousePress, x, y, opts...); err != nil {
+			return err
+		}
+
+		if err := remote.MouseEvent(MouseRelease, x, y, opts...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Click scrolls the target into view and clicks in its center (see Element.ClickablePoint).
+// The target can be a selector (see QuerySelector) or an *Element, other types return a TargetError.
+// The default button is the left button, use the MouseOption options for other buttons or key modifiers.
+//
+// Example:
+//
+//	debugger.Click("text=Sign in")
+//	debugger.Click("#menu", godet.RightButton())
+func (remote *RemoteDebugger) Click(target interface{}, options ...MouseOption) error {
+	x, y, err := remote.targetPoint(target)
+	if err != nil {
+		return err
+	}
+
+	return remote.clickAt(x, y, 1, options)
+}
+
+// DoubleClick scrolls the target into view and double clicks in its center (see Click).
+func (remote *RemoteDebugger) DoubleClick(target interface{}, options ...MouseOption) error {
+	x, y, err := remote.targetPoint(target)
+	if err != nil {
+		return err
+	}
+
+	return remote.clickAt(x, y, 2, options)
+}
+
+// Hover scrolls the target into view and moves the mouse over its center (see Click).
+func (remote *RemoteDebugger) Hover(target interface{}) error {
+	x, y, err := remote.targetPoint(target)
+	if err != nil {
+		return err
+	}
+
+	return remote.MouseEvent(MouseMove, x, y)
+}
+
+// Wheel dispatches a mouse wheel event at the current mouse position, scrolling by dx, dy pixels.
+func (remote *RemoteDebugger) Wheel(dx, dy float64) error {
+	remote.Lock()
+	x, y := remote.mouseX, remote.mouseY
+	remote.Unlock()
+
+	return remote.MouseEvent(MouseWheel, x, y, WheelDelta(dx, dy))
+}
+
+// dispatchDragEvent dispatches a drag event (dragEnter, dragOver, drop or dragCancel) with the intercepted drag data.
+func (remote *RemoteDebugger) dispatchDragEvent(ev string, x, y int, data interface{}) error {
+	_, err := remote.SendRequest("Input.dispatchDragEvent", Params{
+		"type": ev,
+		"x":    x,
+		"y":    y,
+		"data": data,
+	})
+	return err
+}
+
+// DragAndDrop drags the source and drops it on the target (both can be a selector or an *Element, see Click).
+//
+// The mouse is pressed on the source, moved to the target and released. If the source is draggable (HTML5 drag and drop)
+// the drag is intercepted and the drag events are dispatched to the target with the drag data: if the browser doesn't
+// start the drag within the timeout (see DragTimeout) the mouse is released and ErrorDragNotStarted is returned.
+// Note that the source and the target should be visible at the same time, since the page is not scrolled while dragging.
+//
+// Example:
+//
+//	debugger.DragAndDrop("#card-1", "#done-column")
+func (remote *RemoteDebugger) DragAndDrop(source, target interface{}, options ...DragOption) error {
+	opts := dragOptions{timeout: dragInterceptTimeout}
+	for _, o := range options {
+		o(&opts)
+	}
+
+	from, err := remote.targetElement(source)
+	if err != nil {
+		return err
+	}
+
+	to, err := remote.targetElement(target)
+	if err != nil {
+		return err
+	}
+
+	if err := from.ScrollIntoView(); err != nil {
+		return err
+	}
+
+	if err := to.ScrollIntoView(); err != nil {
+		return err
+	}
+
+	p1, err := from.ClickablePoint()
+	if err != nil {
+		return err
+	}
+
+	p2, err := to.ClickablePoint()
+	if err != nil {
+		return err
+	}
+
+	v, err := from.callFunction(draggableJS)
+	if err != nil {
+		return err
+	}
+
+	draggable, _ := v.(bool)
+
+	if _, err := remote.SendRequest("Input.setInterceptDrags", Params{"enabled": true}); err != nil {
+		return err
+	}
+
+	defer remote.SendRequest("Input.setInterceptDrags", Params{"enabled": false})
+
+	dragData := make(chan interface{}, 1)
+
+	removeHandler := remote.addEventHandler("Input.dragIntercepted", func(params Params) {
+		select {
+		case dragData <- params["data"]:
+		default:
+		}
+	})
+	defer removeHandler()
+
+	x1, y1 := int(math.Round(p1.X)), int(math.Round(p1.Y))
+	x2, y2 := int(math.Round(p2.X)), int(math.Round(p2.Y))
+
+	if err := remote.MouseEvent(MouseMove, x1, y1); err != nil {
+		return err
+	}
+
+	if err := remote.MouseEvent(MousePress, x1, y1, LeftButton(), Clicks(1)); err != nil {
+		return err
+	}
+
+	for i := 1; i <= dragSteps; i++ {
+		x := x1 + (x2-x1)*i/dragSteps
+		y := y1 + (y2-y1)*i/dragSteps
+
+		if err := remote.MouseEvent(MouseMove, x, y, LeftButton()); err != nil {
+			return err
+		}
+	}
+
+	var data interface{}
+
+	if draggable {
+		select {
+		case data = <-dragData:
+		case <-time.After(opts.timeout):
+			remote.MouseEvent(MouseRelease, x2, y2, LeftButton(), Clicks(1))
+			return ErrorDragNotStarted
+		}
+	}
+
+	if data != nil {
+		for _, ev := range []string{"dragEnter", "dragOver", "drop"} {
+			if err := remote.dispatchDragEvent(ev, x2, y2, data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return remote.MouseEvent(MouseRelease, x2, y2, LeftButton(), Clicks(1))
+}
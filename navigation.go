@@ -0,0 +1,586 @@
+package godet
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// WaitCondition defines when NavigateAndWait should consider a navigation complete
+type WaitCondition struct {
+	event       string        // lifecycle event name
+	maxInflight int           // for network idle: max number of pending requests
+	quiet       time.Duration // for network idle: how long the network should be idle
+}
+
+var (
+	// UntilCommit waits for the navigation of the main frame to be committed
+	UntilCommit = WaitCondition{event: "commit"}
+	// UntilLoad waits for the load event of the main frame
+	UntilLoad = WaitCondition{event: "load"}
+	// UntilDOMContentLoaded waits for the DOMContentLoaded event of the main frame
+	UntilDOMContentLoaded = WaitCondition{event: "DOMContentLoaded"}
+	// UntilFirstMeaningfulPaint waits for the first meaningful paint of the main frame
+	UntilFirstMeaningfulPaint = WaitCondition{event: "firstMeaningfulPaint"}
+)
+
+// UntilNetworkIdle waits until the navigation is committed and there are no more than
+// maxInflight pending network requests for at least the quiet period.
+func UntilNetworkIdle(maxInflight int, quiet time.Duration) WaitCondition {
+	return WaitCondition{event: "networkIdle", maxInflight: maxInflight, quiet: quiet}
+}
+
+func (c WaitCondition) networkIdle() bool {
+	return c.event == "networkIdle"
+}
+
+// navigationWatcher collects the lifecycle and network events needed to wait for a navigation to complete.
+type navigationWatcher struct {
+	remote *RemoteDebugger
+	until  WaitCondition
+
+	sync.Mutex
+	frameID      string
+	loaderID     string
+	follow       string   // wait for the next navigation of this frame
+	sameDocument bool     // the followed frame navigated within the document
+	bfcache      bool     // the followed frame was restored from the back/forward cache
+	notRestored  []string // reasons why the back/forward cache was not used
+	url          string
+	errorText    string
+	loaders      map[string]*loaderInfo
+
+	tracker *NetworkIdleTracker // only used when waiting for the network to be idle
+	changed chan bool
+	remove  []func()
+}
+
+// loaderInfo contains the information collected for a document load
+type loaderInfo struct {
+	events map[string]bool // lifecycle events received
+	url    string
+	status int
+}
+
+// newNavigationWatcher starts collecting events. It should be called before the navigation request
+// is sent, since events can be received before the reply.
+func (remote *RemoteDebugger) newNavigationWatcher(until WaitCondition) (*navigationWatcher, error) {
+	w := &navigationWatcher{
+		remote:  remote,
+		until:   until,
+		loaders: map[string]*loaderInfo{},
+		changed: make(chan bool, 1),
+	}
+
+	w.remove = []func(){
+		remote.addEventHandler("Page.lifecycleEvent", w.onLifecycle),
+		remote.addEventHandler("Page.frameNavigated", w.onFrameNavigated),
+		remote.addEventHandler("Page.navigatedWithinDocument", w.onNavigatedWithinDocument),
+		remote.addEventHandler("Page.backForwardCacheNotUsed", w.onBackForwardCacheNotUsed),
+		remote.addEventHandler("Network.responseReceived", w.onResponse),
+		remote.addEventHandler("Network.loadingFailed", w.onFailed),
+	}
+
+	if until.networkIdle() {
+		tracker, err := remote.NewNetworkIdleTracker()
+		if err != nil {
+			w.close()
+			return nil, err
+		}
+
+		w.tracker = tracker
+	}
+
+	return w, nil
+}
+
+func (w *navigationWatcher) close() {
+	for _, remove := range w.remove {
+		remove()
+	}
+
+	if w.tracker != nil {
+		w.tracker.Close()
+	}
+}
+
+func (w *navigationWatcher) notify() {
+	select {
+	case w.changed <- true:
+	default:
+	}
+}
+
+// start sets the frame and loader for the navigation to wait for.
+func (w *navigationWatcher) start(frameID, loaderID string) {
+	w.Lock()
+	w.frameID, w.loaderID = frameID, loaderID
+	w.Unlock()
+	w.notify()
+}
+
+// followFrame waits for the next navigation of the specified frame, when the loader is not known in advance.
+func (w *navigationWatcher) followFrame(frameID string) {
+	w.Lock()
+	w.follow = frameID
+	w.Unlock()
+}
+
+// loader returns the information for the specified loader. It should be called with the lock held.
+func (w *navigationWatcher) loader(loaderID string) *loaderInfo {
+	info := w.loaders[loaderID]
+	if info == nil {
+		info = &loaderInfo{events: map[string]bool{}}
+		w.loaders[loaderID] = info
+	}
+
+	return info
+}
+
+func (w *navigationWatcher) onLifecycle(params Params) {
+	w.Lock()
+	w.loader(params.String("loaderId")).events[params.String("name")] = true
+	w.Unlock()
+	w.notify()
+}
+
+func (w *navigationWatcher) onFrameNavigated(params Params) {
+	frame := params.Map("frame")
+	frameID, _ := frame["id"].(string)
+	loaderID, _ := frame["loaderId"].(string)
+	url, _ := frame["url"].(string)
+
+	w.Lock()
+	info := w.loader(loaderID)
+	info.events["commit"] = true
+	info.url = url
+
+	if w.loaderID == "" && w.follow != "" && w.follow == frameID {
+		w.frameID, w.loaderID = frameID, loaderID
+
+		// a restored page is already loaded and doesn't generate lifecycle events
+		w.bfcache = params.String("type") == "BackForwardCacheRestore"
+	}
+	w.Unlock()
+	w.notify()
+}
+
+func (w *navigationWatcher) onNavigatedWithinDocument(params Params) {
+	w.Lock()
+	if w.loaderID == "" && w.follow != "" && w.follow == params.String("frameId") {
+		w.frameID, w.sameDocument, w.url = w.follow, true, params.String("url")
+	}
+	w.Unlock()
+	w.notify()
+}
+
+func (w *navigationWatcher) onBackForwardCacheNotUsed(params Params) {
+	explanations, _ := params["notRestoredExplanations"].([]interface{})
+
+	w.Lock()
+	if w.follow != "" && w.follow == params.String("frameId") {
+		for _, e := range explanations {
+			if reason, _ := e.(map[string]interface{})["reason"].(string); reason != "" {
+				w.notRestored = append(w.notRestored, reason)
+			}
+		}
+	}
+	w.Unlock()
+}
+
+func (w *navigationWatcher) onResponse(params Params) {
+	if params.String("requestId") != params.String("loaderId") {
+		return // not a document request
+	}
+
+	resp := params.Map("response")
+	status, _ := resp["status"].(float64)
+
+	w.Lock()
+	w.loader(params.String("loaderId")).status = int(status)
+	w.Unlock()
+}
+
+func (w *navigationWatcher) onFailed(params Params) {
+	requestID := params.String("requestId")
+
+	w.Lock()
+	if requestID == w.loaderID && !params.Bool("canceled") {
+		w.errorText = params.String("errorText")
+	}
+	w.Unlock()
+	w.notify()
+}
+
+// reached returns true if the lifecycle event the watcher is waiting for was received
+// (for network idle, the navigation should be committed first)
+func (w *navigationWatcher) reached() bool {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.sameDocument || w.bfcache {
+		return true
+	}
+
+	if w.loaderID == "" {
+		return false
+	}
+
+	events := w.loader(w.loaderID).events
+
+	if w.until.networkIdle() {
+		return events["commit"]
+	}
+
+	return events[w.until.event]
+}
+
+// wait waits until the condition is satisfied, the navigation fails or the context is done.
+func (w *navigationWatcher) wait(ctx context.Context) error {
+	for !w.reached() {
+		w.Lock()
+		errorText := w.errorText
+		w.Unlock()
+
+		if errorText != "" {
+			return NavigationError(errorText)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-w.changed:
+		}
+	}
+
+	if w.tracker != nil && !w.sameDocument && !w.bfcache {
+		return w.tracker.WaitIdle(ctx, w.until.maxInflight, w.until.quiet)
+	}
+
+	return nil
+}
+
+// result returns the final URL and HTTP status of the navigation.
+func (w *navigationWatcher) result() (string, int) {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.sameDocument {
+		return w.url, 0
+	}
+
+	info := w.loader(w.loaderID)
+	return info.url, info.status
+}
+
+// enableLifecycleEvents enables the events required by the navigation watcher.
+func (remote *RemoteDebugger) enableLifecycleEvents() error {
+	if err := remote.PageEvents(true); err != nil {
+		return err
+	}
+
+	if err := remote.NetworkEvents(true); err != nil {
+		return err
+	}
+
+	_, err := remote.SendRequest("Page.setLifecycleEventsEnabled", Params{
+		"enabled": true,
+	})
+	return err
+}
+
+// NavigateAndWait navigates to the specified URL and waits until the navigation of the main frame
+// satisfies the specified condition (UntilCommit, UntilLoad, UntilDOMContentLoaded, UntilNetworkIdle or UntilFirstMeaningfulPaint).
+//
+// It returns the final URL (after redirects) and the HTTP status of the main document.
+// If the navigation fails a NavigationError is returned, if the context is done first the context error is returned.
+//
+// Note that this enables Page and Network events.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//
+//	url, status, err := debugger.NavigateAndWait(ctx, "https://example.com", godet.UntilNetworkIdle(0, 500*time.Millisecond))
+func (remote *RemoteDebugger) NavigateAndWait(ctx context.Context, url string, until WaitCondition) (string, int, error) {
+	return remote.navigateAndWait(ctx, Params{"url": url}, until)
+}
+
+// navigateAndWait sends a Page.navigate request with the specified parameters and waits for the navigation to complete.
+func (remote *RemoteDebugger) navigateAndWait(ctx context.Context, params Params, until WaitCondition) (string, int, error) {
+	if err := remote.enableLifecycleEvents(); err != nil {
+		return "", 0, err
+	}
+
+	w, err := remote.newNavigationWatcher(until)
+	if err != nil {
+		return "", 0, err
+	}
+
+	defer w.close()
+
+	res, err := remote.sendRequestContext(ctx, "Page.navigate", params)
+	if err != nil {
+		return "", 0, err
+	}
+
+	var nav struct {
+		FrameID   string `json:"frameId"`
+		LoaderID  string `json:"loaderId"`
+		ErrorText string `json:"errorText"`
+	}
+
+	if err := json.Unmarshal(res, &nav); err != nil {
+		return "", 0, err
+	}
+
+	if nav.ErrorText != "" {
+		return "", 0, NavigationError(nav.ErrorText)
+	}
+
+	if nav.LoaderID == "" { // same document navigation
+		return params.String("url"), 0, nil
+	}
+
+	w.start(nav.FrameID, nav.LoaderID)
+
+	if err := w.wait(ctx); err != nil {
+		return "", 0, err
+	}
+
+	url, status := w.result()
+	return url, status, nil
+}
+
+// waitNavigation waits for the next navigation of the specified frame to satisfy the wait condition
+// and returns the (closed) watcher, to collect the results.
+// If action is not nil, it is called to trigger the navigation after the watcher is ready.
+func (remote *RemoteDebugger) waitNavigation(ctx context.Context, frameID string, until WaitCondition, action func() error) (*navigationWatcher, error) {
+	if err := remote.enableLifecycleEvents(); err != nil {
+		return nil, err
+	}
+
+	w, err := remote.newNavigationWatcher(until)
+	if err != nil {
+		return nil, err
+	}
+
+	defer w.close()
+
+	w.followFrame(frameID)
+
+	if action != nil {
+		if err := action(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// HistoryNavigation describes the result of a history navigation (see GoBackAndWait).
+type HistoryNavigation struct {
+	URL                string   // the final URL
+	Status             int      // HTTP status of the document (0 for same document navigations and back/forward cache restores)
+	BackForwardCache   bool     // true if the page was restored from the back/forward cache
+	NotRestoredReasons []string // reasons why the back/forward cache was not used, if reported by Page.backForwardCacheNotUsed
+}
+
+// mainFrameID returns the id of the main frame.
+func (remote *RemoteDebugger) mainFrameID() (string, error) {
+	rawReply, err := remote.sendRawReplyRequest("Page.getFrameTree", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var res struct {
+		FrameTree frameTreeNode `json:"frameTree"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return "", err
+	}
+
+	return res.FrameTree.Frame.ID, nil
+}
+
+// GoToHistoryEntryAndWait navigates to the history entry with the specified id and waits until the navigation
+// of the main frame satisfies the specified condition (see NavigateAndWait).
+// Pages restored from the back/forward cache and same document navigations complete as soon as they are committed.
+func (remote *RemoteDebugger) GoToHistoryEntryAndWait(ctx context.Context, entryID int64, until WaitCondition) (*HistoryNavigation, error) {
+	frameID, err := remote.mainFrameID()
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := remote.waitNavigation(ctx, frameID, until, func() error {
+		return remote.GoToHistoryEntry(entryID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url, status := w.result()
+
+	w.Lock()
+	defer w.Unlock()
+
+	return &HistoryNavigation{
+		URL:                url,
+		Status:             status,
+		BackForwardCache:   w.bfcache,
+		NotRestoredReasons: w.notRestored,
+	}, nil
+}
+
+// GoBackAndWait navigates to the previous history entry and waits for the navigation to complete (see GoToHistoryEntryAndWait).
+func (remote *RemoteDebugger) GoBackAndWait(ctx context.Context, until WaitCondition) (*HistoryNavigation, error) {
+	entry, err := remote.historyEntry(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.GoToHistoryEntryAndWait(ctx, entry.ID, until)
+}
+
+// GoForwardAndWait navigates to the next history entry and waits for the navigation to complete (see GoToHistoryEntryAndWait).
+func (remote *RemoteDebugger) GoForwardAndWait(ctx context.Context, until WaitCondition) (*HistoryNavigation, error) {
+	entry, err := remote.historyEntry(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.GoToHistoryEntryAndWait(ctx, entry.ID, until)
+}
+
+// NavigationRequest describes a navigation (or redirect) of the main frame, as intercepted by SetNavigationPolicy.
+type NavigationRequest struct {
+	RequestID  string            // Fetch request id
+	FrameID    string            // main frame id
+	URL        string            // requested URL
+	Method     string            // HTTP method
+	Headers    map[string]string // HTTP request headers
+	IsRedirect bool              // true if the request is a redirect
+}
+
+// NavigationPolicy decides how a main frame navigation should be handled:
+//
+//	NavigationProceed allows the navigation
+//	NavigationCancel cancels the navigation (the page shows an error)
+//	NavigationCancelAndIgnore cancels the navigation as if it was never requested
+type NavigationPolicy func(req NavigationRequest) NavigationResponse
+
+// SetNavigationPolicy intercepts the Document requests of the main frame (including redirects),
+// via Fetch.requestPaused, and calls the policy to decide if the navigation should proceed.
+// Use a nil policy to disable navigation control.
+//
+// The policy is called in its own goroutine. Note that this enables the Fetch domain
+// with its own request patterns, replacing patterns set with EnableRequestPaused.
+//
+// Example:
+//
+//	debugger.SetNavigationPolicy(func(req godet.NavigationRequest) godet.NavigationResponse {
+//	    if strings.HasPrefix(req.URL, "https://example.com/") {
+//	        return godet.NavigationProceed
+//	    }
+//
+//	    return godet.NavigationCancelAndIgnore
+//	})
+func (remote *RemoteDebugger) SetNavigationPolicy(policy NavigationPolicy) error {
+	remote.Lock()
+	remove := remote.navPolicy
+	remote.navPolicy = nil
+	remote.Unlock()
+
+	if remove != nil {
+		remove()
+	}
+
+	if policy == nil {
+		return remote.EnableRequestPaused(false)
+	}
+
+	frameID, err := remote.mainFrameID()
+	if err != nil {
+		return err
+	}
+
+	var lock sync.Mutex
+
+	removeNavigated := remote.addEventHandler("Page.frameNavigated", func(params Params) {
+		frame := params.Map("frame")
+		if _, ok := frame["parentId"]; !ok {
+			lock.Lock()
+			frameID, _ = frame["id"].(string)
+			lock.Unlock()
+		}
+	})
+
+	removePaused := remote.addEventHandler("Fetch.requestPaused", func(params Params) {
+		lock.Lock()
+		mainFrame := frameID
+		lock.Unlock()
+
+		requestID := params.String("requestId")
+		request := params.Map("request")
+
+		if params.String("resourceType") != string(ResourceTypeDocument) ||
+			params.String("frameId") != mainFrame ||
+			params["responseStatusCode"] != nil || params["responseErrorReason"] != nil {
+			remote.handleAsync(func() {
+				remote.ContinueRequest(requestID, "", "", "", nil)
+			})
+			return
+		}
+
+		req := NavigationRequest{
+			RequestID:  requestID,
+			FrameID:    mainFrame,
+			IsRedirect: params.String("redirectedRequestId") != "",
+			Headers:    map[string]string{},
+		}
+
+		req.URL, _ = request["url"].(string)
+		req.Method, _ = request["method"].(string)
+
+		if headers, ok := request["headers"].(map[string]interface{}); ok {
+			for k, v := range headers {
+				req.Headers[k], _ = v.(string)
+			}
+		}
+
+		remote.handleAsync(func() {
+			switch policy(req) {
+			case NavigationCancel:
+				remote.FailRequest(requestID, ErrorReasonBlockedByClient)
+			case NavigationCancelAndIgnore:
+				remote.FailRequest(requestID, ErrorReasonAborted)
+			default:
+				remote.ContinueRequest(requestID, "", "", "", nil)
+			}
+		})
+	})
+
+	remote.Lock()
+	remote.navPolicy = func() {
+		removeNavigated()
+		removePaused()
+	}
+	remote.Unlock()
+
+	if err := remote.PageEvents(true); err != nil {
+		return err
+	}
+
+	return remote.EnableRequestPaused(true, FetchRequestPattern{
+		ResourceType: ResourceTypeDocument,
+		RequestStage: RequestStageRequest,
+	})
+}
@@ -0,0 +1,207 @@
+package godet
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NetworkIdleOption defines the functional option for NewNetworkIdleTracker
+type NetworkIdleOption func(t *NetworkIdleTracker)
+
+// IgnoreResourceTypes excludes requests of the specified types from the pending requests
+// (i.e. ResourceTypeEventSource, ResourceTypeWebSocket or ResourceTypeXHR for long-polling connections)
+func IgnoreResourceTypes(types ...ResourceType) NetworkIdleOption {
+	return func(t *NetworkIdleTracker) {
+		for _, rt := range types {
+			t.ignore[rt] = true
+		}
+	}
+}
+
+// NetworkIdleTracker tracks in-flight network requests, per frame, and allows waiting
+// until the network is (almost) idle.
+//
+// Example:
+//
+//	tracker, err := debugger.NewNetworkIdleTracker(godet.IgnoreResourceTypes(godet.ResourceTypeWebSocket))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer tracker.Close()
+//
+//	debugger.Navigate("https://example.com")
+//
+//	// wait until there are no more than 2 pending requests for 500ms
+//	err := tracker.WaitIdle(ctx, 2, 500*time.Millisecond)
+type NetworkIdleTracker struct {
+	remote *RemoteDebugger
+	ignore map[ResourceType]bool
+
+	sync.Mutex
+	requests map[string]string // frameId, by requestId
+	waiters  map[*idleWaiter]bool
+	remove   []func()
+}
+
+// idleWaiter is a pending WaitIdle/WaitFrameIdle call
+type idleWaiter struct {
+	frameID     string
+	maxInflight int
+	idleSince   time.Time
+	changed     chan bool
+}
+
+// NewNetworkIdleTracker starts tracking network requests.
+// Note that this enables Network events: if they cannot be enabled the error is returned,
+// since the tracker would not see any request.
+func (remote *RemoteDebugger) NewNetworkIdleTracker(options ...NetworkIdleOption) (*NetworkIdleTracker, error) {
+	t := &NetworkIdleTracker{
+		remote:   remote,
+		ignore:   map[ResourceType]bool{},
+		requests: map[string]string{},
+		waiters:  map[*idleWaiter]bool{},
+	}
+
+	for _, o := range options {
+		o(t)
+	}
+
+	t.remove = []func(){
+		remote.addEventHandler("Network.requestWillBeSent", func(params Params) {
+			t.started(params.String("requestId"), params.String("frameId"), ResourceType(params.String("type")))
+		}),
+		remote.addEventHandler("Network.webSocketCreated", func(params Params) {
+			t.started(params.String("requestId"), "", ResourceTypeWebSocket)
+		}),
+		remote.addEventHandler("Network.loadingFinished", t.finished),
+		remote.addEventHandler("Network.loadingFailed", t.finished),
+		remote.addEventHandler("Network.requestServedFromCache", t.finished),
+		remote.addEventHandler("Network.webSocketClosed", t.finished),
+	}
+
+	if err := remote.NetworkEvents(true); err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Close stops tracking network requests.
+func (t *NetworkIdleTracker) Close() {
+	for _, remove := range t.remove {
+		remove()
+	}
+}
+
+func (t *NetworkIdleTracker) started(requestID, frameID string, rtype ResourceType) {
+	if t.ignore[rtype] {
+		return
+	}
+
+	t.Lock()
+	t.requests[requestID] = frameID
+	t.update()
+	t.Unlock()
+}
+
+func (t *NetworkIdleTracker) finished(params Params) {
+	t.Lock()
+	delete(t.requests, params.String("requestId"))
+	t.update()
+	t.Unlock()
+}
+
+// update refreshes the idle state of the waiters. It should be called with the lock held.
+func (t *NetworkIdleTracker) update() {
+	for w := range t.waiters {
+		if t.pending(w.frameID) > w.maxInflight {
+			w.idleSince = time.Time{}
+		} else if w.idleSince.IsZero() {
+			w.idleSince = time.Now()
+		}
+
+		select {
+		case w.changed <- true:
+		default:
+		}
+	}
+}
+
+func (t *NetworkIdleTracker) pending(frameID string) (n int) {
+	for _, fid := range t.requests {
+		if frameID == "" || fid == frameID {
+			n++
+		}
+	}
+
+	return
+}
+
+// Pending returns the number of in-flight requests for the specified frame (or for all frames if frameID is empty).
+func (t *NetworkIdleTracker) Pending(frameID string) int {
+	t.Lock()
+	defer t.Unlock()
+
+	return t.pending(frameID)
+}
+
+// WaitIdle waits until there are no more than maxInflight pending requests, in all frames, for at least the quiet period.
+// It returns the context error if the context is done first.
+func (t *NetworkIdleTracker) WaitIdle(ctx context.Context, maxInflight int, quiet time.Duration) error {
+	return t.WaitFrameIdle(ctx, "", maxInflight, quiet)
+}
+
+// WaitFrameIdle waits until there are no more than maxInflight pending requests for the specified frame
+// for at least the quiet period.
+// It returns the context error if the context is done first.
+func (t *NetworkIdleTracker) WaitFrameIdle(ctx context.Context, frameID string, maxInflight int, quiet time.Duration) error {
+	w := &idleWaiter{frameID: frameID, maxInflight: maxInflight, changed: make(chan bool, 1)}
+
+	t.Lock()
+	if t.pending(frameID) <= maxInflight {
+		w.idleSince = time.Now()
+	}
+	t.waiters[w] = true
+	t.Unlock()
+
+	defer func() {
+		t.Lock()
+		delete(t.waiters, w)
+		t.Unlock()
+	}()
+
+	for {
+		t.Lock()
+		idleSince := w.idleSince
+		t.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+
+		if !idleSince.IsZero() {
+			wait := quiet - time.Since(idleSince)
+			if wait <= 0 {
+				return nil
+			}
+
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-w.changed:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
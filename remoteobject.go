@@ -0,0 +1,221 @@
+package godet
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// RemoteObject is a handle to a JavaScript value in the page (Runtime.RemoteObject).
+// Primitive values are returned by value, objects are kept alive in the page until released
+// (with Release, or with ReleaseObjectGroup for the object group).
+type RemoteObject struct {
+	Type                string      `json:"type"`                          // object, function, undefined, string, number, boolean, symbol or bigint
+	Subtype             string      `json:"subtype,omitempty"`             // for objects: array, node, map, set, promise, error, etc.
+	ClassName           string      `json:"className,omitempty"`           // object class (constructor) name
+	Description         string      `json:"description,omitempty"`         // string representation of the object
+	ObjectID            string      `json:"objectId,omitempty"`            // remote object id (empty for primitive values)
+	Value               interface{} `json:"value,omitempty"`               // primitive value
+	UnserializableValue string      `json:"unserializableValue,omitempty"` // primitive value that can't be represented in JSON (i.e. NaN, -0, bigint)
+
+	remote *RemoteDebugger
+}
+
+// newRemoteObject returns the handle for the remote object returned by Runtime methods.
+func (remote *RemoteDebugger) newRemoteObject(object map[string]interface{}) (*RemoteObject, error) {
+	b, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &RemoteObject{remote: remote}
+	if err := json.Unmarshal(b, o); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// callArgument returns the Runtime.CallArgument for a function argument:
+// remote objects are passed by reference, other values by value.
+func callArgument(arg interface{}) Params {
+	if o, ok := arg.(*RemoteObject); ok && o != nil {
+		switch {
+		case o.ObjectID != "":
+			return Params{"objectId": o.ObjectID}
+
+		case o.UnserializableValue != "":
+			return Params{"unserializableValue": o.UnserializableValue}
+
+		case o.Type == "undefined":
+			return Params{}
+
+		default:
+			return Params{"value": o.Value}
+		}
+	}
+
+	return Params{"value": arg}
+}
+
+// EvaluateHandle evaluates the expression in the global context and returns a handle to the result
+// (promises are awaited). Contrary to Evaluate, the result is not serialized, so it can be a DOM node, a function,
+// a Map or any other object. If the expression results in an error, an EvaluateError is returned.
+//
+// Use the ObjectGroup option to put the resulting objects (and the objects returned by the handle methods) in a group,
+// that can be released at once with ReleaseObjectGroup.
+//
+// Example:
+//
+//	links, err := debugger.EvaluateHandle("document.links", godet.ObjectGroup("links"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer debugger.ReleaseObjectGroup("links")
+//
+//	props, _ := links.GetProperties(true)
+//	for _, p := range props {
+//	    if link, _ := p.AsElement(); link != nil {
+//	        href, _ := link.Attr("href")
+//	        fmt.Println(href)
+//	    }
+//	}
+func (remote *RemoteDebugger) EvaluateHandle(expr string, options ...EvaluateOption) (*RemoteObject, error) {
+	params := Params{
+		"expression":    expr,
+		"returnByValue": false,
+		"awaitPromise":  true,
+	}
+
+	for _, opt := range options {
+		opt(params)
+	}
+
+	res, err := remote.evaluateObject(context.Background(), params)
+	if err != nil {
+		return nil, err
+	}
+
+	return remote.newRemoteObject(res)
+}
+
+// CallFunctionOn calls the JavaScript function with the object as `this` and returns a handle to the result
+// (promises are awaited). The arguments can be other handles (passed by reference) or values that can be
+// serialized to JSON. The result belongs to the same object group as the object.
+//
+// Example:
+//
+//	body, _ := debugger.EvaluateHandle("document.body")
+//	div, _ := debugger.EvaluateHandle("document.createElement('div')")
+//	body.CallFunctionOn("function(child) { this.appendChild(child); }", div)
+func (o *RemoteObject) CallFunctionOn(fn string, args ...interface{}) (*RemoteObject, error) {
+	if o.ObjectID == "" {
+		return nil, ErrorNotObject
+	}
+
+	res, err := o.remote.callFunctionOn(o.ObjectID, fn, false, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.remote.newRemoteObject(res)
+}
+
+// GetProperties returns handles to the object properties, by name (for arrays, the indices are the names).
+// If ownProperties is true only the object own properties are returned, otherwise also the properties of the prototype chain.
+// Accessor properties without a value are not returned.
+func (o *RemoteObject) GetProperties(ownProperties bool) (map[string]*RemoteObject, error) {
+	if o.ObjectID == "" {
+		return nil, ErrorNotObject
+	}
+
+	rawReply, err := o.remote.sendRawReplyRequest("Runtime.getProperties", Params{
+		"objectId":      o.ObjectID,
+		"ownProperties": ownProperties,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		Result []struct {
+			Name  string        `json:"name"`
+			Value *RemoteObject `json:"value"`
+		} `json:"result"`
+		ExceptionDetails map[string]interface{} `json:"exceptionDetails"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	if res.ExceptionDetails != nil {
+		return nil, EvaluateError{ExceptionDetails: res.ExceptionDetails}
+	}
+
+	props := map[string]*RemoteObject{}
+
+	for _, p := range res.Result {
+		if p.Value == nil {
+			continue
+		}
+
+		p.Value.remote = o.remote
+		props[p.Name] = p.Value
+	}
+
+	return props, nil
+}
+
+// JSONValue returns the value of the object, serialized with JSON.stringify in the page and decoded
+// (objects as map[string]interface{}, arrays as []interface{}, numbers as float64).
+// Values that JSON.stringify can't serialize (i.e. circular structures, bigint) return an EvaluateError,
+// DOM nodes return ErrorNotSerializable (use AsElement instead) and undefined or functions return nil.
+func (o *RemoteObject) JSONValue() (interface{}, error) {
+	if o.ObjectID == "" {
+		if o.UnserializableValue != "" {
+			return o.UnserializableValue, nil
+		}
+
+		return o.Value, nil
+	}
+
+	if o.Subtype == "node" {
+		return nil, ErrorNotSerializable
+	}
+
+	res, err := o.remote.callFunctionOn(o.ObjectID, "function() { return JSON.stringify(this); }", true)
+	if err != nil {
+		return nil, err
+	}
+
+	text, ok := res["value"].(string)
+	if !ok {
+		return nil, nil
+	}
+
+	var v interface{}
+	err = json.Unmarshal([]byte(text), &v)
+	return v, err
+}
+
+// AsElement returns the element for a DOM node handle, or nil if the object is not a node.
+func (o *RemoteObject) AsElement() (*Element, error) {
+	if o.ObjectID == "" || o.Subtype != "node" {
+		return nil, nil
+	}
+
+	return o.remote.ElementForObject(o.ObjectID)
+}
+
+// Release releases the object in the page. The handle cannot be used after it has been released.
+func (o *RemoteObject) Release() error {
+	if o.ObjectID == "" {
+		return nil
+	}
+
+	return o.remote.ReleaseObject(o.ObjectID)
+}
@@ -0,0 +1,281 @@
+package godet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Selector engines. A selector can be prefixed with the engine name (i.e. `xpath=//div[@id="main"]`),
+// otherwise it's a CSS selector (or an XPath expression, if it starts with `//` or `(//`).
+const (
+	// SelectorCSS matches elements with a CSS selector (the default)
+	SelectorCSS = "css"
+	// SelectorXPath matches nodes with an XPath expression, evaluated relative to the query root
+	SelectorXPath = "xpath"
+	// SelectorText matches the smallest elements containing the specified visible text:
+	//
+	//	text=Log in       case-insensitive substring match (whitespace is normalized)
+	//	text="Log in"     exact match (case-sensitive, whitespace is normalized)
+	//	text=/log ?in/i   regular expression match
+	SelectorText = "text"
+)
+
+// parseSelector splits a selector in the engine name and the engine specific selector.
+func parseSelector(selector string) (engine, value string) {
+	if i := strings.Index(selector, "="); i > 0 {
+		switch e := strings.TrimSpace(selector[:i]); e {
+		case SelectorCSS, SelectorXPath, SelectorText:
+			return e, strings.TrimSpace(selector[i+1:])
+		}
+	}
+
+	if strings.HasPrefix(selector, "//") || strings.HasPrefix(selector, "(//") {
+		return SelectorXPath, selector
+	}
+
+	return SelectorCSS, selector
+}
+
+// selectorEngineJS returns the first node (or the list of nodes) under root matching the selector.
+// If deep is true the query traverses open shadow roots.
+const selectorEngineJS = `(function(root, engine, value, all, deep) {
+	function normalize(s) {
+		return s.replace(/\s+/g, " ").trim();
+	}
+
+	function matcher(value) {
+		if (value.length > 1 && value[0] === '"' && value[value.length-1] === '"') {
+			var exact = normalize(JSON.parse(value));
+			return function(t) { return t === exact; };
+		}
+
+		var m = value.match(/^\/(.*)\/([a-z]*)$/);
+		if (m) {
+			var re = new RegExp(m[1], m[2]);
+			return function(t) { re.lastIndex = 0; return re.test(t); };
+		}
+
+		var sub = normalize(value).toLowerCase();
+		return function(t) { return t.toLowerCase().indexOf(sub) >= 0; };
+	}
+
+	function text(el) {
+		return normalize((el.innerText !== undefined ? el.innerText : el.textContent) || "");
+	}
+
+	var skip = {SCRIPT: true, STYLE: true, NOSCRIPT: true, TEMPLATE: true, HEAD: true};
+
+	function byText(root, value, all) {
+		var match = matcher(value), found = [];
+		var elements = Array.prototype.slice.call(root.querySelectorAll("*"));
+		if (root.nodeType === Node.ELEMENT_NODE) elements.unshift(root);
+
+		for (var i = 0; i < elements.length; i++) {
+			var el = elements[i];
+			if (skip[el.tagName] || !match(text(el))) continue;
+
+			// only return the innermost matching elements
+			var inner = false;
+			for (var c = el.firstElementChild; c && !inner; c = c.nextElementSibling) {
+				inner = !skip[c.tagName] && match(text(c));
+			}
+
+			if (!inner) {
+				if (!all) return el;
+				found.push(el);
+			}
+		}
+
+		return all ? found : null;
+	}
+
+	function byXPath(root, value, all) {
+		var doc = root.ownerDocument || root;
+		if (!all) {
+			return doc.evaluate(value, root, null, XPathResult.FIRST_ORDERED_NODE_TYPE, null).singleNodeValue;
+		}
+
+		var res = doc.evaluate(value, root, null, XPathResult.ORDERED_NODE_SNAPSHOT_TYPE, null), found = [];
+		for (var i = 0; i < res.snapshotLength; i++) found.push(res.snapshotItem(i));
+		return found;
+	}
+
+	function query(root) {
+		switch (engine) {
+		case "xpath":
+			return byXPath(root, value, all);
+		case "text":
+			return byText(root, value, all);
+		default:
+			return all ? Array.prototype.slice.call(root.querySelectorAll(value)) : root.querySelector(value);
+		}
+	}
+
+	if (!deep) return query(root);
+
+	var roots = [root], found = [];
+	for (var i = 0; i < roots.length; i++) {
+		var elements = roots[i].querySelectorAll("*");
+		for (var j = 0; j < elements.length; j++) {
+			if (elements[j].shadowRoot) roots.push(elements[j].shadowRoot);
+		}
+	}
+
+	for (var i = 0; i < roots.length; i++) {
+		var res = query(roots[i]);
+		if (!all && res) return res;
+		if (all) found = found.concat(res);
+	}
+
+	return all ? found : null;
+})`
+
+// queryGroup is used to generate unique object group names for selector queries
+var queryGroup int64
+
+// newQueryGroup returns a new object group name, to release all the objects created by a query.
+func newQueryGroup() string {
+	return fmt.Sprintf("godet-query-%d", atomic.AddInt64(&queryGroup, 1))
+}
+
+// resolveBackendNode returns the object id for the node with the specified backend id, in the specified object group.
+func (remote *RemoteDebugger) resolveBackendNode(backendNodeID int, group string) (string, error) {
+	res, err := remote.SendRequest("DOM.resolveNode", Params{
+		"backendNodeId": backendNodeID,
+		"objectGroup":   group,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	object, _ := res["object"].(map[string]interface{})
+	objectID, _ := object["objectId"].(string)
+	if objectID == "" {
+		return "", ErrorNodeDetached
+	}
+
+	return objectID, nil
+}
+
+// queryObjects runs the selector engine in the page, starting from the root object, and calls found
+// with the object id of each matching node. If deep is true the query traverses open shadow roots.
+//
+// The result objects inherit the object group of the root object.
+func (remote *RemoteDebugger) queryObjects(rootID, engine, value string, all, deep bool, found func(objectID string) error) error {
+	result, err := remote.callFunctionOn(rootID,
+		"function(engine, value, all, deep) { return "+selectorEngineJS+"(this, engine, value, all, deep); }",
+		false, engine, value, all, deep)
+	if err != nil {
+		return err
+	}
+
+	resultID, _ := result["objectId"].(string)
+	if resultID == "" { // null: no match
+		return nil
+	}
+
+	if !all {
+		return found(resultID)
+	}
+
+	props, err := remote.SendRequest("Runtime.getProperties", Params{
+		"objectId":      resultID,
+		"ownProperties": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	list, _ := props["result"].([]interface{})
+
+	for _, p := range list {
+		prop, _ := p.(map[string]interface{})
+		if name, _ := prop["name"].(string); name == "" {
+			continue
+		} else if _, err := strconv.Atoi(name); err != nil { // not an array index
+			continue
+		}
+
+		v, _ := prop["value"].(map[string]interface{})
+		if oid, _ := v["objectId"].(string); oid != "" {
+			if err := found(oid); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// querySelectorEngine runs the selector engine in the page and returns the ids of the matching nodes.
+func (remote *RemoteDebugger) querySelectorEngine(nodeID int, engine, value string, all bool) ([]int, error) {
+	group := newQueryGroup()
+	defer remote.ReleaseObjectGroup(group)
+
+	res, err := remote.SendRequest("DOM.resolveNode", Params{
+		"nodeId":      nodeID,
+		"objectGroup": group,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	object, _ := res["object"].(map[string]interface{})
+	objectID, _ := object["objectId"].(string)
+	if objectID == "" {
+		return nil, ErrorNoResponse
+	}
+
+	var ids []int
+
+	err = remote.queryObjects(objectID, engine, value, all, false, func(oid string) error {
+		id, err := remote.RequestNodeForObject(oid)
+		if err == nil {
+			ids = append(ids, id)
+		}
+		return err
+	})
+
+	return ids, err
+}
+
+// ReleaseObjectGroup releases all the remote objects that belong to the specified group (see ObjectGroup).
+func (remote *RemoteDebugger) ReleaseObjectGroup(group string) error {
+	_, err := remote.SendRequest("Runtime.releaseObjectGroup", Params{
+		"objectGroup": group,
+	})
+	return err
+}
+
+// closedShadowRoots returns the backend node ids of the closed shadow roots under the node described by DOM.describeNode.
+func closedShadowRoots(node map[string]interface{}) (ids []int) {
+	roots, _ := node["shadowRoots"].([]interface{})
+	for _, r := range roots {
+		root, _ := r.(map[string]interface{})
+		if t, _ := root["shadowRootType"].(string); t == "closed" {
+			if id, _ := root["backendNodeId"].(float64); id != 0 {
+				ids = append(ids, int(id))
+			}
+		}
+
+		ids = append(ids, closedShadowRoots(root)...)
+	}
+
+	for _, key := range []string{"children", "templateContent", "contentDocument"} {
+		switch v := node[key].(type) {
+		case []interface{}:
+			for _, c := range v {
+				if child, ok := c.(map[string]interface{}); ok {
+					ids = append(ids, closedShadowRoots(child)...)
+				}
+			}
+
+		case map[string]interface{}:
+			ids = append(ids, closedShadowRoots(v)...)
+		}
+	}
+
+	return
+}
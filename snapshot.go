@@ -0,0 +1,552 @@
+package godet
+
+import (
+	"encoding/json"
+)
+
+// SnapshotOption defines the functional options for CaptureDOMSnapshot
+type SnapshotOption func(p Params)
+
+// SnapshotStyles sets the list of computed styles to capture for each layout node (i.e. "display", "color")
+func SnapshotStyles(names ...string) SnapshotOption {
+	return func(p Params) {
+		p["computedStyles"] = names
+	}
+}
+
+// SnapshotPaintOrder captures the paint order of the layout nodes
+func SnapshotPaintOrder() SnapshotOption {
+	return func(p Params) {
+		p["includePaintOrder"] = true
+	}
+}
+
+// SnapshotDOMRects captures the offset, scroll and client rectangles of the layout nodes
+func SnapshotDOMRects() SnapshotOption {
+	return func(p Params) {
+		p["includeDOMRects"] = true
+	}
+}
+
+// Rect is a rectangle, in CSS pixels
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// newRect returns the rectangle for a [x, y, width, height] array, or nil if the array is not valid
+func newRect(r []float64) *Rect {
+	if len(r) < 4 {
+		return nil
+	}
+
+	return &Rect{X: r[0], Y: r[1], Width: r[2], Height: r[3]}
+}
+
+// DOMSnapshot is a static view of the page (see CaptureDOMSnapshot).
+// The first document is the main document, the other documents are iframe documents.
+type DOMSnapshot struct {
+	Documents []*DocumentSnapshot `json:"documents"`
+}
+
+// DocumentSnapshot is a snapshot of a document.
+type DocumentSnapshot struct {
+	URL             string  `json:"url"`
+	Title           string  `json:"title"`
+	BaseURL         string  `json:"baseURL,omitempty"`
+	ContentLanguage string  `json:"contentLanguage,omitempty"`
+	EncodingName    string  `json:"encodingName,omitempty"`
+	FrameID         string  `json:"frameId,omitempty"`
+	ScrollOffsetX   float64 `json:"scrollOffsetX"`
+	ScrollOffsetY   float64 `json:"scrollOffsetY"`
+	ContentWidth    float64 `json:"contentWidth"`
+	ContentHeight   float64 `json:"contentHeight"`
+
+	Root *SnapshotNode `json:"root"`
+}
+
+// SnapshotNode is a node in a document snapshot.
+type SnapshotNode struct {
+	NodeType         int               `json:"nodeType"`
+	NodeName         string            `json:"nodeName"`
+	NodeValue        string            `json:"nodeValue,omitempty"`
+	BackendNodeID    int               `json:"backendNodeId"`
+	Attributes       map[string]string `json:"attributes,omitempty"`
+	TextValue        string            `json:"textValue,omitempty"`    // value of textarea elements
+	InputValue       string            `json:"inputValue,omitempty"`   // value of input elements
+	InputChecked     bool              `json:"inputChecked,omitempty"` // checked state of radio and checkbox elements
+	OptionSelected   bool              `json:"optionSelected,omitempty"`
+	IsClickable      bool              `json:"isClickable,omitempty"` // true if the node has a click event listener (or is natively clickable)
+	PseudoType       string            `json:"pseudoType,omitempty"`
+	ShadowRootType   string            `json:"shadowRootType,omitempty"`
+	CurrentSourceURL string            `json:"currentSourceURL,omitempty"`
+	OriginURL        string            `json:"originURL,omitempty"`
+
+	// ContentDocumentIndex is the index of the content document (for iframes) in DOMSnapshot.Documents
+	ContentDocumentIndex int `json:"contentDocumentIndex,omitempty"`
+
+	Layout   *SnapshotLayout `json:"layout,omitempty"` // nil if the node is not rendered
+	Children []*SnapshotNode `json:"children,omitempty"`
+
+	Parent          *SnapshotNode     `json:"-"`
+	Document        *DocumentSnapshot `json:"-"`
+	ContentDocument *DocumentSnapshot `json:"-"`
+}
+
+// SnapshotLayout contains the layout information for a rendered node.
+type SnapshotLayout struct {
+	Bounds          Rect              `json:"bounds"`
+	Text            string            `json:"text,omitempty"`
+	Styles          map[string]string `json:"styles,omitempty"`     // computed styles requested with SnapshotStyles
+	PaintOrder      int               `json:"paintOrder,omitempty"` // requested with SnapshotPaintOrder
+	StackingContext bool              `json:"stackingContext,omitempty"`
+	OffsetRect      *Rect             `json:"offsetRect,omitempty"` // requested with SnapshotDOMRects
+	ScrollRect      *Rect             `json:"scrollRect,omitempty"`
+	ClientRect      *Rect             `json:"clientRect,omitempty"`
+	TextBoxes       []SnapshotTextBox `json:"textBoxes,omitempty"`
+}
+
+// SnapshotTextBox is a post-layout inline text box.
+type SnapshotTextBox struct {
+	Bounds Rect   `json:"bounds"`
+	Start  int    `json:"start"`  // start index in the layout text
+	Length int    `json:"length"` // length in the layout text
+	Text   string `json:"text"`
+}
+
+// wire format for DOMSnapshot.captureSnapshot
+
+type rareStringData struct {
+	Index []int `json:"index"`
+	Value []int `json:"value"`
+}
+
+type rareBooleanData struct {
+	Index []int `json:"index"`
+}
+
+type rareIntegerData struct {
+	Index []int `json:"index"`
+	Value []int `json:"value"`
+}
+
+type nodeTreeSnapshot struct {
+	ParentIndex          []int           `json:"parentIndex"`
+	NodeType             []int           `json:"nodeType"`
+	ShadowRootType       rareStringData  `json:"shadowRootType"`
+	NodeName             []int           `json:"nodeName"`
+	NodeValue            []int           `json:"nodeValue"`
+	BackendNodeID        []int           `json:"backendNodeId"`
+	Attributes           [][]int         `json:"attributes"`
+	TextValue            rareStringData  `json:"textValue"`
+	InputValue           rareStringData  `json:"inputValue"`
+	InputChecked         rareBooleanData `json:"inputChecked"`
+	OptionSelected       rareBooleanData `json:"optionSelected"`
+	ContentDocumentIndex rareIntegerData `json:"contentDocumentIndex"`
+	PseudoType           rareStringData  `json:"pseudoType"`
+	IsClickable          rareBooleanData `json:"isClickable"`
+	CurrentSourceURL     rareStringData  `json:"currentSourceURL"`
+	OriginURL            rareStringData  `json:"originURL"`
+}
+
+type layoutTreeSnapshot struct {
+	NodeIndex        []int           `json:"nodeIndex"`
+	Styles           [][]int         `json:"styles"`
+	Bounds           [][]float64     `json:"bounds"`
+	Text             []int           `json:"text"`
+	StackingContexts rareBooleanData `json:"stackingContexts"`
+	PaintOrders      []int           `json:"paintOrders"`
+	OffsetRects      [][]float64     `json:"offsetRects"`
+	ScrollRects      [][]float64     `json:"scrollRects"`
+	ClientRects      [][]float64     `json:"clientRects"`
+}
+
+type textBoxSnapshot struct {
+	LayoutIndex []int       `json:"layoutIndex"`
+	Bounds      [][]float64 `json:"bounds"`
+	Start       []int       `json:"start"`
+	Length      []int       `json:"length"`
+}
+
+type documentSnapshot struct {
+	DocumentURL     int                `json:"documentURL"`
+	Title           int                `json:"title"`
+	BaseURL         int                `json:"baseURL"`
+	ContentLanguage int                `json:"contentLanguage"`
+	EncodingName    int                `json:"encodingName"`
+	FrameID         int                `json:"frameId"`
+	Nodes           nodeTreeSnapshot   `json:"nodes"`
+	Layout          layoutTreeSnapshot `json:"layout"`
+	TextBoxes       textBoxSnapshot    `json:"textBoxes"`
+	ScrollOffsetX   float64            `json:"scrollOffsetX"`
+	ScrollOffsetY   float64            `json:"scrollOffsetY"`
+	ContentWidth    float64            `json:"contentWidth"`
+	ContentHeight   float64            `json:"contentHeight"`
+}
+
+// CaptureDOMSnapshot returns a snapshot of the page: the DOM tree of the main document and iframe documents,
+// with layout and text box information for the rendered nodes.
+//
+// Example:
+//
+//	snapshot, err := debugger.CaptureDOMSnapshot(godet.SnapshotStyles("display", "color"))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//
+//	links := snapshot.Filter(func(n *godet.SnapshotNode) bool {
+//	    return n.NodeName == "A" && n.Layout != nil
+//	})
+func (remote *RemoteDebugger) CaptureDOMSnapshot(options ...SnapshotOption) (*DOMSnapshot, error) {
+	params := Params{"computedStyles": []string{}}
+	for _, o := range options {
+		o(params)
+	}
+
+	rawReply, err := remote.sendRawReplyRequest("DOMSnapshot.captureSnapshot", params)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawReply == nil {
+		return nil, ErrorNoResponse
+	}
+
+	var res struct {
+		Documents []documentSnapshot `json:"documents"`
+		Strings   []string           `json:"strings"`
+	}
+
+	if err := json.Unmarshal(rawReply, &res); err != nil {
+		return nil, err
+	}
+
+	styles, _ := params["computedStyles"].([]string)
+
+	snapshot := &DOMSnapshot{Documents: make([]*DocumentSnapshot, len(res.Documents))}
+	for i := range res.Documents {
+		snapshot.Documents[i] = &DocumentSnapshot{}
+	}
+
+	for i, doc := range res.Documents {
+		decodeDocument(snapshot, snapshot.Documents[i], doc, res.Strings, styles)
+	}
+
+	return snapshot, nil
+}
+
+// decodeDocument builds the document tree from the wire format.
+func decodeDocument(snapshot *DOMSnapshot, d *DocumentSnapshot, doc documentSnapshot, strs []string, styles []string) {
+	str := func(i int) string {
+		if i < 0 || i >= len(strs) {
+			return ""
+		}
+
+		return strs[i]
+	}
+
+	rareString := func(data rareStringData, set func(n int, s string)) {
+		for i, n := range data.Index {
+			if i < len(data.Value) {
+				set(n, str(data.Value[i]))
+			}
+		}
+	}
+
+	rareBool := func(data rareBooleanData, set func(n int)) {
+		for _, n := range data.Index {
+			set(n)
+		}
+	}
+
+	d.URL = str(doc.DocumentURL)
+	d.Title = str(doc.Title)
+	d.BaseURL = str(doc.BaseURL)
+	d.ContentLanguage = str(doc.ContentLanguage)
+	d.EncodingName = str(doc.EncodingName)
+	d.FrameID = str(doc.FrameID)
+	d.ScrollOffsetX = doc.ScrollOffsetX
+	d.ScrollOffsetY = doc.ScrollOffsetY
+	d.ContentWidth = doc.ContentWidth
+	d.ContentHeight = doc.ContentHeight
+
+	nodes := doc.Nodes
+	list := make([]*SnapshotNode, len(nodes.NodeType))
+
+	for i := range list {
+		n := &SnapshotNode{NodeType: nodes.NodeType[i], Document: d}
+
+		if i < len(nodes.NodeName) {
+			n.NodeName = str(nodes.NodeName[i])
+		}
+
+		if i < len(nodes.NodeValue) {
+			n.NodeValue = str(nodes.NodeValue[i])
+		}
+
+		if i < len(nodes.BackendNodeID) {
+			n.BackendNodeID = nodes.BackendNodeID[i]
+		}
+
+		if i < len(nodes.Attributes) && len(nodes.Attributes[i]) > 0 {
+			attrs := nodes.Attributes[i]
+			n.Attributes = map[string]string{}
+
+			for j := 0; j+1 < len(attrs); j += 2 {
+				n.Attributes[str(attrs[j])] = str(attrs[j+1])
+			}
+		}
+
+		list[i] = n
+	}
+
+	get := func(i int) *SnapshotNode {
+		if i < 0 || i >= len(list) {
+			return nil
+		}
+
+		return list[i]
+	}
+
+	set := func(f func(n *SnapshotNode, s string)) func(int, string) {
+		return func(i int, s string) {
+			if n := get(i); n != nil {
+				f(n, s)
+			}
+		}
+	}
+
+	rareString(nodes.ShadowRootType, set(func(n *SnapshotNode, s string) { n.ShadowRootType = s }))
+	rareString(nodes.TextValue, set(func(n *SnapshotNode, s string) { n.TextValue = s }))
+	rareString(nodes.InputValue, set(func(n *SnapshotNode, s string) { n.InputValue = s }))
+	rareString(nodes.PseudoType, set(func(n *SnapshotNode, s string) { n.PseudoType = s }))
+	rareString(nodes.CurrentSourceURL, set(func(n *SnapshotNode, s string) { n.CurrentSourceURL = s }))
+	rareString(nodes.OriginURL, set(func(n *SnapshotNode, s string) { n.OriginURL = s }))
+
+	rareBool(nodes.InputChecked, func(i int) {
+		if n := get(i); n != nil {
+			n.InputChecked = true
+		}
+	})
+
+	rareBool(nodes.OptionSelected, func(i int) {
+		if n := get(i); n != nil {
+			n.OptionSelected = true
+		}
+	})
+
+	rareBool(nodes.IsClickable, func(i int) {
+		if n := get(i); n != nil {
+			n.IsClickable = true
+		}
+	})
+
+	for i, ni := range nodes.ContentDocumentIndex.Index {
+		if n := get(ni); n != nil && i < len(nodes.ContentDocumentIndex.Value) {
+			di := nodes.ContentDocumentIndex.Value[i]
+			if di >= 0 && di < len(snapshot.Documents) {
+				n.ContentDocumentIndex = di
+				n.ContentDocument = snapshot.Documents[di]
+			}
+		}
+	}
+
+	// build the tree (parents always precede their children)
+	for i, n := range list {
+		parent := -1
+		if i < len(nodes.ParentIndex) {
+			parent = nodes.ParentIndex[i]
+		}
+
+		if p := get(parent); p != nil {
+			n.Parent = p
+			p.Children = append(p.Children, n)
+		} else if d.Root == nil {
+			d.Root = n
+		}
+	}
+
+	// layout
+	layout := doc.Layout
+	layouts := make([]*SnapshotLayout, len(layout.NodeIndex))
+
+	for i, ni := range layout.NodeIndex {
+		l := &SnapshotLayout{}
+
+		if i < len(layout.Bounds) {
+			if r := newRect(layout.Bounds[i]); r != nil {
+				l.Bounds = *r
+			}
+		}
+
+		if i < len(layout.Text) {
+			l.Text = str(layout.Text[i])
+		}
+
+		if i < len(layout.Styles) && len(styles) > 0 {
+			l.Styles = map[string]string{}
+
+			for j, si := range layout.Styles[i] {
+				if j < len(styles) {
+					l.Styles[styles[j]] = str(si)
+				}
+			}
+		}
+
+		if i < len(layout.PaintOrders) {
+			l.PaintOrder = layout.PaintOrders[i]
+		}
+
+		if i < len(layout.OffsetRects) {
+			l.OffsetRect = newRect(layout.OffsetRects[i])
+		}
+
+		if i < len(layout.ScrollRects) {
+			l.ScrollRect = newRect(layout.ScrollRects[i])
+		}
+
+		if i < len(layout.ClientRects) {
+			l.ClientRect = newRect(layout.ClientRects[i])
+		}
+
+		layouts[i] = l
+
+		if n := get(ni); n != nil {
+			n.Layout = l
+		}
+	}
+
+	for _, li := range layout.StackingContexts.Index {
+		if li >= 0 && li < len(layouts) {
+			layouts[li].StackingContext = true
+		}
+	}
+
+	// text boxes
+	boxes := doc.TextBoxes
+
+	for i, li := range boxes.LayoutIndex {
+		if li < 0 || li >= len(layouts) {
+			continue
+		}
+
+		l := layouts[li]
+		tb := SnapshotTextBox{}
+
+		if i < len(boxes.Bounds) {
+			if r := newRect(boxes.Bounds[i]); r != nil {
+				tb.Bounds = *r
+			}
+		}
+
+		if i < len(boxes.Start) && i < len(boxes.Length) {
+			tb.Start, tb.Length = boxes.Start[i], boxes.Length[i]
+
+			// start and length are in UTF-16 code units
+			tb.Text = utf16Slice(l.Text, tb.Start, tb.Length)
+		}
+
+		l.TextBoxes = append(l.TextBoxes, tb)
+	}
+}
+
+// utf16Slice returns the substring of s at the specified UTF-16 offset and length.
+func utf16Slice(s string, start, length int) string {
+	var b []rune
+	pos := 0
+
+	for _, r := range s {
+		w := 1
+		if r >= 0x10000 {
+			w = 2
+		}
+
+		if pos >= start+length {
+			break
+		}
+
+		if pos >= start {
+			b = append(b, r)
+		}
+
+		pos += w
+	}
+
+	return string(b)
+}
+
+// Walk calls fn for the node and all its descendants (including the content of iframe documents), in document order.
+// If fn returns false the node children are skipped.
+func (n *SnapshotNode) Walk(fn func(n *SnapshotNode) bool) {
+	if n == nil || !fn(n) {
+		return
+	}
+
+	for _, c := range n.Children {
+		c.Walk(fn)
+	}
+
+	if n.ContentDocument != nil {
+		n.ContentDocument.Root.Walk(fn)
+	}
+}
+
+// Filter returns the node and the descendants for which fn returns true, in document order.
+func (n *SnapshotNode) Filter(fn func(n *SnapshotNode) bool) (nodes []*SnapshotNode) {
+	n.Walk(func(n *SnapshotNode) bool {
+		if fn(n) {
+			nodes = append(nodes, n)
+		}
+
+		return true
+	})
+
+	return
+}
+
+// Attr returns the value of the specified attribute (or an empty string if the node doesn't have the attribute).
+func (n *SnapshotNode) Attr(name string) string {
+	return n.Attributes[name]
+}
+
+// Text returns the text content of the node (the concatenated value of the descendant text nodes).
+// The content of iframe documents is not included.
+func (n *SnapshotNode) Text() string {
+	if n.NodeType == 3 { // TEXT_NODE
+		return n.NodeValue
+	}
+
+	var text string
+	for _, c := range n.Children {
+		text += c.Text()
+	}
+
+	return text
+}
+
+// Root returns the root node of the main document (or nil if the snapshot is empty).
+func (s *DOMSnapshot) Root() *SnapshotNode {
+	if len(s.Documents) == 0 {
+		return nil
+	}
+
+	return s.Documents[0].Root
+}
+
+// Walk calls fn for all the nodes in the snapshot, in document order (see SnapshotNode.Walk).
+func (s *DOMSnapshot) Walk(fn func(n *SnapshotNode) bool) {
+	s.Root().Walk(fn)
+}
+
+// Filter returns all the nodes in the snapshot for which fn returns true, in document order.
+func (s *DOMSnapshot) Filter(fn func(n *SnapshotNode) bool) []*SnapshotNode {
+	return s.Root().Filter(fn)
+}
+
+// JSON returns the snapshot serialized as (indented) JSON.
+// Iframe documents are referenced by their index in the documents list (contentDocumentIndex).
+func (s *DOMSnapshot) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
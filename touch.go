@@ -0,0 +1,193 @@
+package godet
+
+import (
+	"time"
+)
+
+// TouchEventType is the type of a touch event (see TouchEvent).
+type TouchEventType string
+
+const (
+	TouchStart  TouchEventType = "touchStart"
+	TouchMove   TouchEventType = "touchMove"
+	TouchEnd    TouchEventType = "touchEnd"
+	TouchCancel TouchEventType = "touchCancel"
+)
+
+// TouchPoint is a touch point, in CSS pixels relative to the viewport.
+// Each finger in a multi-touch event should have a different ID, that is kept for the duration of the touch.
+type TouchPoint struct {
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	ID      int     `json:"id"`
+	RadiusX float64 `json:"radiusX,omitempty"`
+	RadiusY float64 `json:"radiusY,omitempty"`
+	Force   float64 `json:"force,omitempty"`
+}
+
+// swipeSteps is the number of touch moves in a Swipe
+const swipeSteps = 10
+
+// SetTouchEmulation enables or disables touch events emulation, with the maximum number of touch points supported
+// (i.e. to emulate a mobile device, together with SetDeviceMetricsOverride).
+func (remote *RemoteDebugger) SetTouchEmulation(enable bool, maxTouchPoints int) error {
+	params := Params{
+		"enabled": enable,
+	}
+
+	if maxTouchPoints > 0 {
+		params["maxTouchPoints"] = maxTouchPoints
+	}
+
+	_, err := remote.SendRequest("Emulation.setTouchEmulationEnabled", params)
+	return err
+}
+
+// TouchEvent dispatches a touch event to the page.
+// TouchStart and TouchMove events contain all the active touch points, TouchEnd and TouchCancel events
+// contain the touch points that are still active (an empty list when the last finger is lifted).
+func (remote *RemoteDebugger) TouchEvent(ev TouchEventType, points []TouchPoint, modifiers KeyModifier) error {
+	if points == nil {
+		points = []TouchPoint{}
+	}
+
+	_, err := remote.SendRequest("Input.dispatchTouchEvent", Params{
+		"type":        ev,
+		"touchPoints": points,
+		"modifiers":   modifiers,
+	})
+	return err
+}
+
+// Tap scrolls the target into view and taps in its center.
+// The target can be a selector (see QuerySelector) or an *Element.
+// Note that touch emulation should be enabled (see SetTouchEmulation) for the page to receive touch events.
+func (remote *RemoteDebugger) Tap(target interface{}) error {
+	x, y, err := remote.targetPoint(target)
+	if err != nil {
+		return err
+	}
+
+	if err := remote.TouchEvent(TouchStart, []TouchPoint{{X: float64(x), Y: float64(y)}}, NoModifier); err != nil {
+		return err
+	}
+
+	return remote.TouchEvent(TouchEnd, nil, NoModifier)
+}
+
+// Swipe touches the page at from, moves the finger to to in the specified duration and lifts it.
+//
+// Example:
+//
+//	// swipe left, in the middle of the viewport
+//	debugger.Swipe(godet.Point{X: 300, Y: 300}, godet.Point{X: 50, Y: 300}, 200*time.Millisecond)
+func (remote *RemoteDebugger) Swipe(from, to Point, duration time.Duration) error {
+	if err := remote.TouchEvent(TouchStart, []TouchPoint{{X: from.X, Y: from.Y}}, NoModifier); err != nil {
+		return err
+	}
+
+	for i := 1; i <= swipeSteps; i++ {
+		time.Sleep(duration / swipeSteps)
+
+		p := TouchPoint{
+			X: from.X + (to.X-from.X)*float64(i)/swipeSteps,
+			Y: from.Y + (to.Y-from.Y)*float64(i)/swipeSteps,
+		}
+
+		if err := remote.TouchEvent(TouchMove, []TouchPoint{p}, NoModifier); err != nil {
+			return err
+		}
+	}
+
+	return remote.TouchEvent(TouchEnd, nil, NoModifier)
+}
+
+// GestureOption defines the functional options for the synthesized gestures
+type GestureOption func(p Params)
+
+// GestureSource sets the input device used for the gesture: "touch", "mouse" or "default" (the platform default)
+func GestureSource(source string) GestureOption {
+	return func(p Params) {
+		p["gestureSourceType"] = source
+	}
+}
+
+// GestureSpeed sets the speed of the gesture: pixels per second for ScrollGesture, relative speed for PinchGesture
+// (the defaults are 800 and 800).
+func GestureSpeed(speed int) GestureOption {
+	return func(p Params) {
+		p["speed"] = speed
+		p["relativeSpeed"] = speed
+	}
+}
+
+// GestureDuration sets the duration of each touch for TapGesture (the default is 50ms)
+func GestureDuration(d time.Duration) GestureOption {
+	return func(p Params) {
+		p["duration"] = int(d / time.Millisecond)
+	}
+}
+
+// TapCount sets the number of taps for TapGesture (the default is 1)
+func TapCount(count int) GestureOption {
+	return func(p Params) {
+		p["tapCount"] = count
+	}
+}
+
+// GestureRepeat repeats the ScrollGesture count times, waiting delay between repetitions
+func GestureRepeat(count int, delay time.Duration) GestureOption {
+	return func(p Params) {
+		p["repeatCount"] = count
+		p["repeatDelayMs"] = int(delay / time.Millisecond)
+	}
+}
+
+// synthesizeGesture sends a synthesize gesture request, keeping only the options supported by the method.
+func (remote *RemoteDebugger) synthesizeGesture(method string, params Params, supported []string, options []GestureOption) error {
+	opts := Params{}
+	for _, o := range options {
+		o(opts)
+	}
+
+	for _, k := range append(supported, "gestureSourceType") {
+		if v, ok := opts[k]; ok {
+			params[k] = v
+		}
+	}
+
+	_, err := remote.SendRequest(method, params)
+	return err
+}
+
+// PinchGesture synthesizes a pinch gesture centered at x, y (in CSS pixels relative to the viewport),
+// with a scale factor greater than 1 to zoom in and less than 1 to zoom out.
+// It returns when the gesture is completed.
+func (remote *RemoteDebugger) PinchGesture(x, y, scaleFactor float64, options ...GestureOption) error {
+	return remote.synthesizeGesture("Input.synthesizePinchGesture", Params{
+		"x":           x,
+		"y":           y,
+		"scaleFactor": scaleFactor,
+	}, []string{"relativeSpeed"}, options)
+}
+
+// ScrollGesture synthesizes a scroll gesture starting at x, y (in CSS pixels relative to the viewport).
+// The distances are the finger movement, so negative values scroll the content down or right (as for a swipe).
+// It returns when the gesture is completed.
+func (remote *RemoteDebugger) ScrollGesture(x, y, dx, dy float64, options ...GestureOption) error {
+	return remote.synthesizeGesture("Input.synthesizeScrollGesture", Params{
+		"x":         x,
+		"y":         y,
+		"xDistance": dx,
+		"yDistance": dy,
+	}, []string{"speed", "repeatCount", "repeatDelayMs"}, options)
+}
+
+// TapGesture synthesizes a tap gesture at x, y (in CSS pixels relative to the viewport).
+// It returns when the gesture is completed.
+func (remote *RemoteDebugger) TapGesture(x, y float64, options ...GestureOption) error {
+	return remote.synthesizeGesture("Input.synthesizeTapGesture", Params{
+		"x": x,
+		"y": y,
+	}, []string{"duration", "tapCount"}, options)
+}
@@ -0,0 +1,243 @@
+package godet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SelectorState defines the element state WaitForSelector should wait for
+type SelectorState string
+
+const (
+	// StateAttached waits for the element to be present in the DOM
+	StateAttached = SelectorState("attached")
+	// StateDetached waits for the element to be removed from the DOM
+	StateDetached = SelectorState("detached")
+	// StateVisible waits for the element to be present and visible (with a non-empty bounding box)
+	StateVisible = SelectorState("visible")
+	// StateHidden waits for the element to be removed from the DOM or hidden
+	StateHidden = SelectorState("hidden")
+)
+
+// PollingMode defines how often a wait condition is checked in the page
+type PollingMode struct {
+	mode     string
+	interval time.Duration
+}
+
+var (
+	// PollRAF checks the condition on every animation frame
+	PollRAF = PollingMode{mode: "raf"}
+	// PollMutation checks the condition on every DOM mutation
+	PollMutation = PollingMode{mode: "mutation"}
+)
+
+// PollInterval checks the condition at the specified interval
+func PollInterval(interval time.Duration) PollingMode {
+	return PollingMode{mode: "interval", interval: interval}
+}
+
+// TimeoutError is returned by the wait helpers if the condition is not satisfied in time.
+type TimeoutError string
+
+// Error implements the error interface for TimeoutError.
+func (err TimeoutError) Error() string {
+	return "TimeoutError:" + string(err)
+}
+
+// waitTimeout is the error message used by the in-page waiter on timeout
+const waitTimeout = "godet:timeout"
+
+// waitCancelled is the error message used by the in-page waiter when it's stopped (see stopWaiter)
+const waitCancelled = "godet:cancelled"
+
+// waiterID is used to generate unique ids for the in-page waiters
+var waiterID int64
+
+// waitJS returns a promise that resolves to the value of the predicate, when the value is truthy.
+// The waiter registers a stop function in window.__godetWaiters, by id, to stop polling when the wait is cancelled.
+const waitJS = `(function(predicate, polling, interval, timeout, id) {
+	return new Promise(function(resolve, reject) {
+		var done = false, timer = null, observer = null;
+		var waiters = window.__godetWaiters = window.__godetWaiters || {};
+
+		function finish(fn, v) {
+			if (done) return;
+			done = true;
+			delete waiters[id];
+			if (observer) observer.disconnect();
+			if (timer) clearTimeout(timer);
+			fn(v);
+		}
+
+		waiters[id] = function() { finish(reject, new Error("` + waitCancelled + `")); };
+
+		function check() {
+			try {
+				var v = predicate();
+				if (v) finish(resolve, v);
+			} catch (e) {
+				finish(reject, e instanceof Error ? e : new Error(String(e)));
+			}
+			return done;
+		}
+
+		if (timeout > 0) {
+			timer = setTimeout(function() { finish(reject, new Error("` + waitTimeout + `")); }, timeout);
+		}
+
+		if (check()) return;
+
+		if (polling === "raf") {
+			(function loop() { if (!check()) requestAnimationFrame(loop); })();
+		} else if (polling === "mutation") {
+			observer = new MutationObserver(check);
+			observer.observe(document, {childList: true, subtree: true, attributes: true, characterData: true});
+		} else {
+			(function loop() { if (!check()) setTimeout(loop, interval); })();
+		}
+	});
+})`
+
+// selectorJS returns the element matching the selector, or true, if the element is in the expected state.
+// The query function is selectorEngineJS.
+const selectorJS = `(function(query, engine, selector, state) {
+	function visible(el) {
+		var style = window.getComputedStyle(el);
+		var rect = el.getBoundingClientRect();
+		return style.visibility !== "hidden" && style.display !== "none" && rect.width > 0 && rect.height > 0;
+	}
+
+	return function() {
+		var el = query(document, engine, selector, false);
+
+		switch (state) {
+		case "attached":
+			return el;
+		case "detached":
+			return !el;
+		case "visible":
+			return el && el.nodeType === Node.ELEMENT_NODE && visible(el) ? el : null;
+		case "hidden":
+			return !el || el.nodeType !== Node.ELEMENT_NODE || !visible(el);
+		}
+	};
+})`
+
+// jsString returns the JavaScript literal for the specified string.
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// waitFor evaluates a wait expression and returns the resulting remote object.
+func (remote *RemoteDebugger) waitFor(ctx context.Context, predicate string, polling PollingMode, returnByValue bool, what string) (map[string]interface{}, error) {
+	if polling.mode == "" {
+		polling = PollRAF
+	}
+
+	if polling.interval <= 0 {
+		polling.interval = 100 * time.Millisecond
+	}
+
+	var timeout time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		if timeout = time.Until(deadline); timeout <= 0 {
+			return nil, TimeoutError(what)
+		}
+	}
+
+	id := atomic.AddInt64(&waiterID, 1)
+
+	expr := fmt.Sprintf("%s(%s, %q, %d, %d, %d)", waitJS, predicate, polling.mode,
+		polling.interval/time.Millisecond, timeout/time.Millisecond, id)
+
+	res, err := remote.evaluateObject(ctx, Params{
+		"expression":    expr,
+		"awaitPromise":  true,
+		"returnByValue": returnByValue,
+	})
+
+	if err != nil && ctx.Err() != nil {
+		// the context was cancelled (or expired) before the page replied: stop polling in the page
+		remote.stopWaiter(id)
+	}
+
+	if err == context.DeadlineExceeded {
+		return nil, TimeoutError(what)
+	}
+
+	if eerr, ok := err.(EvaluateError); ok {
+		if desc, _ := eerr.ErrorDetails["description"].(string); strings.Contains(desc, waitTimeout) {
+			return nil, TimeoutError(what)
+		}
+	}
+
+	return res, err
+}
+
+// stopWaiter stops the in-page waiter with the specified id, if it's still running.
+func (remote *RemoteDebugger) stopWaiter(id int64) {
+	remote.evaluateObject(context.Background(), Params{
+		"expression": fmt.Sprintf("window.__godetWaiters && window.__godetWaiters[%d] && window.__godetWaiters[%d]()", id, id),
+	})
+}
+
+// WaitForSelector waits for the element matching the selector to be in the specified state
+// (StateAttached, StateVisible, StateHidden or StateDetached). The check runs in the page, on every animation frame.
+// See QuerySelector for the selector syntax.
+//
+// For StateAttached and StateVisible it returns the element node id, otherwise it returns 0.
+// If the context deadline expires first a TimeoutError is returned.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//
+//	nodeID, err := debugger.WaitForSelector(ctx, "#results .item", godet.StateVisible)
+func (remote *RemoteDebugger) WaitForSelector(ctx context.Context, selector string, state SelectorState) (int, error) {
+	engine, value := parseSelector(selector)
+	predicate := fmt.Sprintf("%s(%s, %q, %s, %q)", selectorJS, selectorEngineJS, engine, jsString(value), state)
+	what := fmt.Sprintf("waiting for selector %q to be %v", selector, state)
+
+	res, err := remote.waitFor(ctx, predicate, PollRAF, false, what)
+	if err != nil {
+		return 0, err
+	}
+
+	objectID, _ := res["objectId"].(string)
+	if objectID == "" { // hidden or detached
+		return 0, nil
+	}
+
+	defer remote.ReleaseObject(objectID)
+	return remote.RequestNodeForObject(objectID)
+}
+
+// WaitForFunction waits for the JavaScript expression to return a truthy value and returns the value.
+// The expression is evaluated in the page, according to the polling mode (PollRAF, PollMutation or PollInterval).
+//
+// If the context deadline expires first a TimeoutError is returned.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//
+//	count, err := debugger.WaitForFunction(ctx, "document.querySelectorAll('li').length >= 10 && document.querySelectorAll('li').length", godet.PollMutation)
+func (remote *RemoteDebugger) WaitForFunction(ctx context.Context, expr string, polling PollingMode) (interface{}, error) {
+	predicate := fmt.Sprintf("function() { return (%s); }", expr)
+	what := fmt.Sprintf("waiting for function %q", expr)
+
+	res, err := remote.waitFor(ctx, predicate, polling, true, what)
+	if err != nil {
+		return nil, err
+	}
+
+	return res["value"], nil
+}